@@ -1,9 +1,16 @@
 package cfg
 
 import (
+	"fmt"
 	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"lixiang-monitor/cookie"
 	"lixiang-monitor/notifier"
 	"lixiang-monitor/utils"
 
@@ -26,29 +33,83 @@ type Config struct {
 	EnablePeriodicNotify        bool
 	NotificationIntervalHours   int
 	AlwaysNotifyWhenApproaching bool
+	DigestSchedule              string
+
+	// 通知去重与限流
+	NotificationDedupWindow time.Duration
+	NotificationRateBurst   int
+	NotificationRatePerHour float64
+
+	// 预计时间波动检测
+	FlapStableChecks   int
+	FlapStableDuration time.Duration
+	FlapWindow         time.Duration
 
 	// Cookie 管理
-	CookieUpdatedAt time.Time
-	CookieValidDays int
+	CookieUpdatedAt       time.Time
+	CookieExpiresAt       time.Time
+	CookieValidDays       int
+	CookieStateFile       string
+	CookiePersistToConfig bool
+	KeepaliveSchedule     string
+	KeepaliveURL          string
+
+	// 请求头配置
+	HeaderProfiles []cookie.HeaderProfile
+	HeaderRotation string
+
+	// 聊天命令
+	TelegramBotToken       string
+	TelegramAPIBase        string
+	TelegramAllowedChatIDs []string
+	WeComCallbackToken     string
+	WeComCallbackAESKey    string
+	WeComCorpID            string
+	WeComAllowedUserIDs    []string
+	WeComAgentID           int
+	WeComCorpSecret        string
 
 	// Web 服务器
-	WebEnabled  bool
-	WebPort     int
-	WebBasePath string
+	WebEnabled     bool
+	WebPort        int
+	WebBasePath    string
+	WebCookieToken string
+
+	// Web 访问认证
+	WebAuthUsername string
+	WebAuthPassword string
+	WebAuthToken    string
+	WebSessionHours int
+	CalendarToken   string
+
+	// Web HTTPS
+	WebTLSCertFile     string
+	WebTLSKeyFile      string
+	WebTLSRedirectPort int
+
+	// 健康检查
+	HealthMaxMissedChecks int
 }
 
 // Init 初始化配置系统
 func Init() error {
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(".")
+	if paths.ConfigFile != "" {
+		viper.SetConfigFile(paths.ConfigFile)
+	} else {
+		viper.SetConfigName("config")
+		viper.SetConfigType("yaml")
+		for _, dir := range configSearchPaths() {
+			viper.AddConfigPath(dir)
+		}
+	}
 
 	if err := viper.ReadInConfig(); err != nil {
 		log.Printf("配置文件读取失败: %v", err)
 	}
 
-	// 设置默认值
+	// 设置默认值，并允许环境变量覆盖
 	setDefaults()
+	bindEnv()
 
 	return nil
 }
@@ -56,6 +117,9 @@ func Init() error {
 // setDefaults 设置配置默认值
 func setDefaults() {
 	viper.SetDefault("order_id", "177971759268550919")
+	viper.SetDefault("lixiang_cookies", "")
+	viper.SetDefault("cookie_updated_at", "")
+	viper.SetDefault("cookie_expires_at", "")
 	viper.SetDefault("check_interval", "@every 30m")
 	viper.SetDefault("wechat_webhook_url", "")
 	viper.SetDefault("serverchan_sendkey", "")
@@ -70,10 +134,39 @@ func setDefaults() {
 	viper.SetDefault("enable_periodic_notify", true)
 	viper.SetDefault("notification_interval_hours", 24)
 	viper.SetDefault("always_notify_when_approaching", true)
+	viper.SetDefault("digest_schedule", "")
+	viper.SetDefault("notification_dedup_window_minutes", 360)
+	viper.SetDefault("notification_rate_limit_burst", 5)
+	viper.SetDefault("notification_rate_limit_per_hour", 6)
+	viper.SetDefault("flap_stable_checks", 3)
+	viper.SetDefault("flap_stable_minutes", 0)
+	viper.SetDefault("flap_window_hours", 72)
 	viper.SetDefault("cookie_valid_days", 7)
+	viper.SetDefault("cookie_state_file", "cookie-state.json")
+	viper.SetDefault("cookie_persist_to_config", false)
+	viper.SetDefault("cookie_keepalive_schedule", "")
+	viper.SetDefault("cookie_keepalive_url", "")
+	viper.SetDefault("header_rotation", cookie.RotationNone)
+	viper.SetDefault("telegram_bot_token", "")
+	viper.SetDefault("telegram_api_base", "https://api.telegram.org")
+	viper.SetDefault("wecom_callback_token", "")
+	viper.SetDefault("wecom_callback_aes_key", "")
+	viper.SetDefault("wecom_corp_id", "")
+	viper.SetDefault("wecom_agent_id", 0)
+	viper.SetDefault("wecom_corp_secret", "")
 	viper.SetDefault("web_enabled", true)
 	viper.SetDefault("web_port", 8080)
 	viper.SetDefault("web_base_path", "")
+	viper.SetDefault("web_cookie_token", "")
+	viper.SetDefault("web_auth_username", "")
+	viper.SetDefault("web_auth_password", "")
+	viper.SetDefault("web_auth_token", "")
+	viper.SetDefault("web_session_hours", 24)
+	viper.SetDefault("calendar_token", "")
+	viper.SetDefault("web_tls_cert_file", "")
+	viper.SetDefault("web_tls_key_file", "")
+	viper.SetDefault("web_tls_redirect_port", 0)
+	viper.SetDefault("health_max_missed_checks", 3)
 }
 
 // Load 加载配置并返回 Config 结构
@@ -90,7 +183,7 @@ func Load() (*Config, error) {
 
 	// 基本配置
 	cfg.OrderID = viper.GetString("order_id")
-	cfg.LixiangCookies = viper.GetString("lixiang_cookies")
+	cfg.LixiangCookies = getSecret("lixiang_cookies")
 	cfg.CheckInterval = viper.GetString("check_interval")
 	cfg.LockOrderTime = lockOrderTime
 	cfg.EstimateWeeksMin = viper.GetInt("estimate_weeks_min")
@@ -100,8 +193,19 @@ func Load() (*Config, error) {
 	cfg.EnablePeriodicNotify = viper.GetBool("enable_periodic_notify")
 	cfg.NotificationIntervalHours = viper.GetInt("notification_interval_hours")
 	cfg.AlwaysNotifyWhenApproaching = viper.GetBool("always_notify_when_approaching")
+	cfg.DigestSchedule = viper.GetString("digest_schedule")
 	cfg.Notifiers = loadNotifiers()
 
+	// 通知去重与限流配置
+	cfg.NotificationDedupWindow = time.Duration(viper.GetInt("notification_dedup_window_minutes")) * time.Minute
+	cfg.NotificationRateBurst = viper.GetInt("notification_rate_limit_burst")
+	cfg.NotificationRatePerHour = viper.GetFloat64("notification_rate_limit_per_hour")
+
+	// 预计时间波动检测配置
+	cfg.FlapStableChecks = viper.GetInt("flap_stable_checks")
+	cfg.FlapStableDuration = time.Duration(viper.GetInt("flap_stable_minutes")) * time.Minute
+	cfg.FlapWindow = time.Duration(viper.GetInt("flap_window_hours")) * time.Hour
+
 	// Cookie 配置
 	cfg.CookieValidDays = viper.GetInt("cookie_valid_days")
 	if cfg.CookieValidDays == 0 {
@@ -110,7 +214,7 @@ func Load() (*Config, error) {
 
 	cookieUpdatedStr := viper.GetString("cookie_updated_at")
 	if cookieUpdatedStr != "" {
-		if parsedTime, err := time.Parse(utils.DateTimeFormat, cookieUpdatedStr); err == nil {
+		if parsedTime, err := time.ParseInLocation(utils.DateTimeFormat, cookieUpdatedStr, time.Local); err == nil {
 			cfg.CookieUpdatedAt = parsedTime
 		} else {
 			cfg.CookieUpdatedAt = time.Now()
@@ -119,6 +223,38 @@ func Load() (*Config, error) {
 		cfg.CookieUpdatedAt = time.Now()
 	}
 
+	// 聊天命令配置
+	cfg.TelegramBotToken = getSecret("telegram_bot_token")
+	cfg.TelegramAPIBase = viper.GetString("telegram_api_base")
+	cfg.TelegramAllowedChatIDs = viper.GetStringSlice("telegram_allowed_chat_ids")
+	cfg.WeComCallbackToken = getSecret("wecom_callback_token")
+	cfg.WeComCallbackAESKey = getSecret("wecom_callback_aes_key")
+	cfg.WeComCorpID = viper.GetString("wecom_corp_id")
+	cfg.WeComAllowedUserIDs = viper.GetStringSlice("wecom_allowed_user_ids")
+	cfg.WeComAgentID = viper.GetInt("wecom_agent_id")
+	cfg.WeComCorpSecret = getSecret("wecom_corp_secret")
+
+	if cookieExpiresStr := viper.GetString("cookie_expires_at"); cookieExpiresStr != "" {
+		if parsedTime, err := time.ParseInLocation(utils.DateTimeFormat, cookieExpiresStr, time.Local); err == nil {
+			cfg.CookieExpiresAt = parsedTime
+		} else {
+			log.Printf("Cookie 过期时间解析失败: %v", err)
+		}
+	}
+
+	cfg.CookieStateFile = DataPath(viper.GetString("cookie_state_file"))
+	cfg.CookiePersistToConfig = viper.GetBool("cookie_persist_to_config")
+	cfg.KeepaliveSchedule = viper.GetString("cookie_keepalive_schedule")
+	cfg.KeepaliveURL = viper.GetString("cookie_keepalive_url")
+
+	// 请求头配置
+	cfg.HeaderProfiles = loadHeaderProfiles()
+	cfg.HeaderRotation = viper.GetString("header_rotation")
+	if !cookie.ValidRotation(cfg.HeaderRotation) {
+		log.Printf("⚠️  header_rotation 无效: %s，使用 %s", cfg.HeaderRotation, cookie.RotationNone)
+		cfg.HeaderRotation = cookie.RotationNone
+	}
+
 	// Web 服务器配置
 	cfg.WebEnabled = viper.GetBool("web_enabled")
 	cfg.WebPort = viper.GetInt("web_port")
@@ -126,16 +262,60 @@ func Load() (*Config, error) {
 		cfg.WebPort = 8080
 	}
 	cfg.WebBasePath = viper.GetString("web_base_path")
+	cfg.WebCookieToken = getSecret("web_cookie_token")
+
+	// Web 访问认证配置
+	cfg.WebAuthUsername = viper.GetString("web_auth_username")
+	cfg.WebAuthPassword = getSecret("web_auth_password")
+	cfg.WebAuthToken = getSecret("web_auth_token")
+	cfg.WebSessionHours = viper.GetInt("web_session_hours")
+	cfg.CalendarToken = getSecret("calendar_token")
+	if cfg.WebSessionHours <= 0 {
+		cfg.WebSessionHours = 24
+	}
+
+	// Web HTTPS 配置
+	cfg.WebTLSCertFile = viper.GetString("web_tls_cert_file")
+	cfg.WebTLSKeyFile = viper.GetString("web_tls_key_file")
+	cfg.WebTLSRedirectPort = viper.GetInt("web_tls_redirect_port")
+
+	// 健康检查配置
+	cfg.HealthMaxMissedChecks = viper.GetInt("health_max_missed_checks")
+	if cfg.HealthMaxMissedChecks <= 0 {
+		cfg.HealthMaxMissedChecks = 3
+	}
 
 	return cfg, nil
 }
 
+// loadHeaderProfiles 加载请求头配置，跳过没有 user-agent 的配置
+func loadHeaderProfiles() []cookie.HeaderProfile {
+	var raw []cookie.HeaderProfile
+	if err := viper.UnmarshalKey("header_profiles", &raw); err != nil {
+		log.Printf("⚠️  header_profiles 解析失败: %v", err)
+		return nil
+	}
+
+	profiles := make([]cookie.HeaderProfile, 0, len(raw))
+	for i, profile := range raw {
+		if profile.Name == "" {
+			profile.Name = fmt.Sprintf("profile-%d", i+1)
+		}
+		if _, ok := profile.Headers["user-agent"]; !ok {
+			log.Printf("⚠️  请求头配置 %s 缺少 user-agent，已忽略", profile.Name)
+			continue
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles
+}
+
 // loadNotifiers 加载所有配置的通知器
 func loadNotifiers() []notifier.Notifier {
 	var notifiers []notifier.Notifier
 
 	// 微信群机器人
-	wechatWebhookURL := viper.GetString("wechat_webhook_url")
+	wechatWebhookURL := getSecret("wechat_webhook_url")
 	if wechatWebhookURL != "" {
 		notifiers = append(notifiers, &notifier.WeChatWebhookNotifier{
 			WebhookURL: wechatWebhookURL,
@@ -143,7 +323,7 @@ func loadNotifiers() []notifier.Notifier {
 	}
 
 	// ServerChan
-	serverChanSendKey := viper.GetString("serverchan_sendkey")
+	serverChanSendKey := getSecret("serverchan_sendkey")
 	if serverChanSendKey != "" {
 		notifiers = append(notifiers, &notifier.ServerChanNotifier{
 			SendKey: serverChanSendKey,
@@ -152,7 +332,7 @@ func loadNotifiers() []notifier.Notifier {
 	}
 
 	// Bark
-	barkServerURL := viper.GetString("bark_server_url")
+	barkServerURL := getSecret("bark_server_url")
 	if barkServerURL != "" {
 		notifiers = append(notifiers, &notifier.BarkNotifier{
 			ServerURL: barkServerURL,
@@ -184,6 +364,69 @@ func Watch(callback func()) {
 	log.Println("✅ 配置文件监听已启动")
 }
 
+// SetValues 将顶层配置项写回配置文件，保留文件中其他内容和注释
+// 写入后由配置文件监听触发热加载
+func SetValues(values map[string]string) error {
+	path := viper.ConfigFileUsed()
+	if path == "" {
+		return fmt.Errorf("未找到配置文件")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("读取配置文件信息失败: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	// 按键名排序，保证追加顺序稳定
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// 被环境变量或密钥文件覆盖的配置项写入配置文件不会生效
+	for _, key := range keys {
+		if source := overrideSource(key); source != "" {
+			return fmt.Errorf("%s 由%s提供，无法写入配置文件", key, source)
+		}
+	}
+
+	content := string(data)
+	for _, key := range keys {
+		content = setYAMLValue(content, key, values[key])
+	}
+
+	// 先记录再写入，写入触发的热加载可能在 WriteFile 返回前开始
+	recordSelfWrite(values)
+	if err := os.WriteFile(path, []byte(content), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("写入配置文件失败: %v", err)
+	}
+
+	log.Printf("配置文件已更新: %s", strings.Join(keys, ", "))
+	return nil
+}
+
+// setYAMLValue 替换 YAML 中的顶层标量配置项，不存在时追加到末尾
+func setYAMLValue(content, key, value string) string {
+	line := key + ": " + strconv.Quote(value)
+
+	// 匹配键所在行以及块标量（| 或 >）的缩进续行
+	pattern := regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(key) + `:[^\n]*(\n[ \t]+[^\n]*)*`)
+	if loc := pattern.FindStringIndex(content); loc != nil {
+		return content[:loc[0]] + line + content[loc[1]:]
+	}
+
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + line + "\n"
+}
+
 // GetString 获取字符串配置
 func GetString(key string) string {
 	return viper.GetString(key)
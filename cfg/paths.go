@@ -0,0 +1,122 @@
+package cfg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// AppName 应用名称，用于默认的配置目录和数据目录
+const AppName = "lixiang-monitor"
+
+// DBFileName 默认的数据库文件名
+const DBFileName = "lixiang-monitor.db"
+
+// 用于指定路径的环境变量，优先级低于命令行参数
+const (
+	EnvConfigFile = "LIXIANG_MONITOR_CONFIG"
+	EnvDataDir    = "LIXIANG_MONITOR_DATA_DIR"
+	EnvDBPath     = "LIXIANG_MONITOR_DB"
+)
+
+// Paths 配置文件、数据目录和数据库路径
+type Paths struct {
+	ConfigFile string // 配置文件路径，为空时按默认位置搜索 config.yaml
+	DataDir    string // 数据目录，存放数据库和 Cookie 状态文件
+	DBPath     string // 数据库文件路径
+}
+
+var paths = Paths{DataDir: ".", DBPath: DBFileName}
+
+// SetPaths 根据命令行参数、环境变量和默认值确定路径
+// 参数为空时依次使用环境变量和默认值：
+//   - 当前目录下存在 config.yaml 或数据库文件时沿用当前目录（兼容旧的部署方式）
+//   - 否则配置文件在 $XDG_CONFIG_HOME/lixiang-monitor、/etc/lixiang-monitor 中搜索，
+//     数据目录为 $XDG_DATA_HOME/lixiang-monitor
+func SetPaths(configFile, dataDir, dbPath string) Paths {
+	configFile = firstNonEmpty(configFile, os.Getenv(EnvConfigFile))
+	dataDir = firstNonEmpty(dataDir, os.Getenv(EnvDataDir))
+	dbPath = firstNonEmpty(dbPath, os.Getenv(EnvDBPath))
+
+	if dataDir == "" {
+		if fileExists("config.yaml") || fileExists(DBFileName) {
+			dataDir = "."
+		} else {
+			dataDir = filepath.Join(xdgDir("XDG_DATA_HOME", ".local/share"), AppName)
+		}
+	}
+	if dbPath == "" {
+		dbPath = filepath.Join(dataDir, DBFileName)
+	}
+
+	paths = Paths{ConfigFile: configFile, DataDir: dataDir, DBPath: dbPath}
+	return paths
+}
+
+// GetPaths 返回当前使用的路径
+func GetPaths() Paths {
+	return paths
+}
+
+// DataPath 将相对路径解析到数据目录下，绝对路径保持不变
+func DataPath(name string) string {
+	if name == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(paths.DataDir, name)
+}
+
+// EnsureDataDir 创建数据目录和数据库所在目录，并确认可写
+func EnsureDataDir() error {
+	dirs := []string{paths.DataDir, filepath.Dir(paths.DBPath)}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return fmt.Errorf("无法创建数据目录 %s: %v", dir, err)
+		}
+
+		probe, err := os.CreateTemp(dir, ".write-test-*")
+		if err != nil {
+			return fmt.Errorf("数据目录 %s 不可写: %v", dir, err)
+		}
+		probe.Close()
+		os.Remove(probe.Name())
+	}
+	return nil
+}
+
+// configSearchPaths 未指定配置文件时搜索 config.yaml 的目录，按优先级排列
+func configSearchPaths() []string {
+	return []string{
+		".",
+		filepath.Join(xdgDir("XDG_CONFIG_HOME", ".config"), AppName),
+		filepath.Join("/etc", AppName),
+	}
+}
+
+// xdgDir 读取 XDG 目录环境变量，未设置时使用家目录下的默认位置
+func xdgDir(env, fallback string) string {
+	if dir := os.Getenv(env); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+	return filepath.Join(home, fallback)
+}
+
+// fileExists 判断文件是否存在
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,202 @@
+package cfg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix 环境变量前缀，任意配置项都可以用 LIXIANG_<配置项大写> 覆盖，例如 LIXIANG_ORDER_ID
+const EnvPrefix = "LIXIANG"
+
+// SecretSuffix 敏感配置项的文件后缀，<配置项>_file 指向的文件内容会作为该配置项的值
+const SecretSuffix = "_file"
+
+// SecretKeys 敏感配置项：支持从文件读取，输出和通知时脱敏
+var SecretKeys = []string{
+	"lixiang_cookies",
+	"wechat_webhook_url",
+	"serverchan_sendkey",
+	"bark_server_url",
+	"telegram_bot_token",
+	"wecom_callback_token",
+	"wecom_callback_aes_key",
+	"wecom_corp_secret",
+	"web_cookie_token",
+	"web_auth_password",
+	"web_auth_token",
+	"calendar_token",
+}
+
+// bindEnv 启用环境变量覆盖
+func bindEnv() {
+	viper.SetEnvPrefix(EnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	viper.AutomaticEnv()
+}
+
+// envName 返回配置项对应的环境变量名
+func envName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(key)
+}
+
+// IsSecret 判断配置项是否为敏感配置
+func IsSecret(key string) bool {
+	for _, k := range SecretKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// getSecret 读取敏感配置项，<key>_file 优先于直接配置的值
+// 文件末尾的换行会被去掉，方便使用 echo 或 Kubernetes Secret 生成的文件
+func getSecret(key string) string {
+	path := viper.GetString(key + SecretSuffix)
+	if path == "" {
+		return viper.GetString(key)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("⚠️  读取 %s%s 指定的文件失败: %v", key, SecretSuffix, err)
+		return viper.GetString(key)
+	}
+	return strings.TrimRight(string(data), "\r\n")
+}
+
+// overrideSource 返回覆盖配置文件中该配置项的来源（环境变量或密钥文件），未被覆盖时返回空字符串
+func overrideSource(key string) string {
+	if IsSecret(key) {
+		if path := viper.GetString(key + SecretSuffix); path != "" {
+			return "文件 " + path
+		}
+	}
+	if _, ok := os.LookupEnv(envName(key)); ok {
+		return "环境变量 " + envName(key)
+	}
+	return ""
+}
+
+// Redact 对敏感值脱敏：URL 只保留协议和主机名，其他值只保留前 4 个字符
+func Redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	if u, err := url.Parse(value); err == nil && u.Scheme != "" && u.Host != "" {
+		return u.Scheme + "://" + u.Host + "/****"
+	}
+	// 按字符截取，避免切断多字节字符
+	runes := []rune(value)
+	if len(runes) <= 8 {
+		return "****"
+	}
+	return string(runes[:4]) + "****"
+}
+
+// Settings 返回当前生效的所有配置项（已合并默认值、配置文件、环境变量和密钥文件），敏感项已脱敏
+func Settings() map[string]string {
+	return settings(Redact)
+}
+
+// Snapshot 返回当前生效配置的快照，用于检测配置变更；敏感项只保存摘要
+func Snapshot() map[string]string {
+	return settings(digest)
+}
+
+// digest 返回敏感配置值在快照中的摘要
+func digest(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:8])
+}
+
+// selfWritten SetValues 写入的配置项及其快照值，用于识别由程序自身写入触发的热加载
+var (
+	selfWrittenMu sync.Mutex
+	selfWritten   = make(map[string]string)
+)
+
+// recordSelfWrite 记录 SetValues 写入的配置项
+func recordSelfWrite(values map[string]string) {
+	selfWrittenMu.Lock()
+	defer selfWrittenMu.Unlock()
+
+	for key, value := range values {
+		if IsSecret(key) {
+			value = digest(value)
+		}
+		selfWritten[key] = value
+	}
+}
+
+// SelfWritten 判断两份快照之间的变更是否全部来自 SetValues 的写入（如保存刷新后的 Cookie）
+// 没有任何变更时返回 false
+func SelfWritten(before, after map[string]string) bool {
+	selfWrittenMu.Lock()
+	defer selfWrittenMu.Unlock()
+
+	changed := false
+	for key, value := range after {
+		if old, ok := before[key]; ok && old == value {
+			continue
+		}
+		if written, ok := selfWritten[key]; !ok || written != value {
+			return false
+		}
+		changed = true
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			return false
+		}
+	}
+	return changed
+}
+
+// settings 读取所有配置项，敏感项经过 secret 处理
+func settings(secret func(string) string) map[string]string {
+	keys := viper.AllKeys()
+	keys = append(keys, SecretKeys...)
+
+	result := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if IsSecret(key) {
+			result[key] = secret(getSecret(key))
+			continue
+		}
+		result[key] = fmt.Sprint(viper.Get(key))
+	}
+	return result
+}
+
+// ChangedSettings 比较两份配置快照，返回 "配置项: 旧值 → 新值" 格式的变更列表（敏感项不显示值）
+func ChangedSettings(before, after map[string]string) []string {
+	var changes []string
+	for key, value := range after {
+		old, ok := before[key]
+		if ok && old == value {
+			continue
+		}
+		if IsSecret(key) {
+			changes = append(changes, fmt.Sprintf("%s: 已更新", key))
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("%s: %s → %s", key, old, value))
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			changes = append(changes, fmt.Sprintf("%s: 已删除", key))
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
@@ -0,0 +1,73 @@
+package cfg
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSelfWritten(t *testing.T) {
+	before := map[string]string{
+		"order_id":          "order",
+		"lixiang_cookies":   digest("X-LX-Token=old"),
+		"cookie_updated_at": "2025-10-01 10:00:00",
+	}
+	recordSelfWrite(map[string]string{
+		"lixiang_cookies":   "X-LX-Token=new",
+		"cookie_updated_at": "2025-10-15 10:00:00",
+	})
+
+	written := map[string]string{
+		"order_id":          "order",
+		"lixiang_cookies":   digest("X-LX-Token=new"),
+		"cookie_updated_at": "2025-10-15 10:00:00",
+	}
+	if !SelfWritten(before, written) {
+		t.Error("change made by SetValues not recognised")
+	}
+	if SelfWritten(written, written) {
+		t.Error("snapshot without changes reported as self-written")
+	}
+
+	// 同一次热加载中还有手动修改的配置项时需要通知
+	edited := map[string]string{
+		"order_id":          "other-order",
+		"lixiang_cookies":   digest("X-LX-Token=new"),
+		"cookie_updated_at": "2025-10-15 10:00:00",
+	}
+	if SelfWritten(before, edited) {
+		t.Error("manual edit reported as self-written")
+	}
+
+	// 手动改成与写入不同的值
+	manual := map[string]string{
+		"order_id":          "order",
+		"lixiang_cookies":   digest("X-LX-Token=pasted"),
+		"cookie_updated_at": "2025-10-01 10:00:00",
+	}
+	if SelfWritten(before, manual) {
+		t.Error("manually pasted cookie reported as self-written")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"short", "****"},
+		{"abcdefghijkl", "abcd****"},
+		{"https://hooks.example.com/send?key=secret", "https://hooks.example.com/****"},
+		{"企业微信机器人密钥很长很长", "企业微信****"},
+		{"密钥abcdef", "****"},
+	}
+	for _, tt := range tests {
+		got := Redact(tt.value)
+		if got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("Redact(%q) = %q is not valid UTF-8", tt.value, got)
+		}
+	}
+}
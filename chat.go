@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"lixiang-monitor/chat"
+	"lixiang-monitor/utils"
+)
+
+// initChat 初始化聊天命令（Telegram 轮询和企业微信回调）
+func (m *Monitor) initChat() {
+	m.chatDispatcher = chat.NewDispatcher(m, nil)
+	m.updateChatAllowlist()
+
+	if m.TelegramBotToken != "" {
+		m.telegramBot = chat.NewTelegramBot(m.TelegramBotToken, m.TelegramAPIBase, m.chatDispatcher)
+		log.Println("✅ Telegram 命令已启用")
+	}
+
+	if m.WeComCallbackToken != "" {
+		if m.webServer == nil {
+			log.Println("⚠️  企业微信命令回调需要启用 Web 服务器")
+			return
+		}
+
+		callback, err := chat.NewWeComCallback(m.WeComCallbackToken, m.WeComCallbackAESKey, m.WeComCorpID, m.chatDispatcher)
+		if err != nil {
+			log.Printf("⚠️  企业微信命令回调初始化失败: %v", err)
+			return
+		}
+		if m.WeComCorpSecret != "" && m.WeComAgentID > 0 {
+			callback.SetApp(chat.NewWeComApp(m.WeComCorpID, m.WeComCorpSecret, m.WeComAgentID, ""))
+		} else {
+			log.Println("⚠️  未配置 wecom_corp_secret 和 wecom_agent_id，执行较慢的命令（如 /check）时无法回复结果")
+		}
+		m.wecomCallback = callback
+		m.webServer.Handle("/api/chat/wecom", callback)
+		log.Println("✅ 企业微信命令回调已启用")
+	}
+}
+
+// updateChatAllowlist 更新聊天命令的会话白名单
+func (m *Monitor) updateChatAllowlist() {
+	if m.chatDispatcher == nil {
+		return
+	}
+
+	allowed := make([]string, 0, len(m.TelegramAllowedChatIDs)+len(m.WeComAllowedUserIDs))
+	allowed = append(allowed, m.TelegramAllowedChatIDs...)
+	allowed = append(allowed, m.WeComAllowedUserIDs...)
+	m.chatDispatcher.SetAllowed(allowed)
+}
+
+// Status 实现 chat.Backend 接口
+func (m *Monitor) Status() string {
+	m.mu.RLock()
+	orderID := m.OrderID
+	lastEstimateTime := m.LastEstimateTime
+	m.mu.RUnlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "订单号: %s\n", orderID)
+	if lastEstimateTime != "" {
+		fmt.Fprintf(&b, "官方预计时间: %s\n", lastEstimateTime)
+	} else {
+		b.WriteString("官方预计时间: 尚未获取\n")
+	}
+
+	if m.database != nil {
+		if record, err := m.database.GetLatestRecord(orderID); err == nil && record != nil {
+			fmt.Fprintf(&b, "最后检查: %s\n", record.CheckTime.Format(utils.DateTimeFormat))
+		}
+	}
+
+	if until := m.notificationHandler.MutedUntil(); !until.IsZero() {
+		fmt.Fprintf(&b, "🔕 通知已静音至 %s\n", until.Format(utils.DateTimeShort))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(m.deliveryInfo.GetDetailedDeliveryInfo())
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// Check 实现 chat.Backend 接口
+func (m *Monitor) Check() string {
+	if m.stopping.Load() {
+		return "⏹️ 监控服务正在停止，未执行检查"
+	}
+	m.checkDeliveryTime()
+	return m.Status()
+}
+
+// History 实现 chat.Backend 接口
+func (m *Monitor) History(limit int) string {
+	if m.database == nil {
+		return "数据库未初始化，无法查询历史记录"
+	}
+
+	m.mu.RLock()
+	orderID := m.OrderID
+	m.mu.RUnlock()
+
+	records, err := m.database.GetRecordsByOrderID(orderID, limit)
+	if err != nil {
+		return fmt.Sprintf("查询历史记录失败: %v", err)
+	}
+	if len(records) == 0 {
+		return "暂无检查记录"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "最近 %d 条检查记录:\n", len(records))
+	for _, record := range records {
+		mark := ""
+		if record.TimeChanged {
+			mark = fmt.Sprintf(" (由 %s 变更)", record.PreviousEstimate)
+		}
+		fmt.Fprintf(&b, "%s  %s%s\n", record.CheckTime.Format(utils.DateTimeShort), record.EstimateTime, mark)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// Mute 实现 chat.Backend 接口
+func (m *Monitor) Mute(d time.Duration) string {
+	if d <= 0 {
+		m.notificationHandler.Mute(time.Time{})
+		return "🔔 已取消静音"
+	}
+
+	until := time.Now().Add(d)
+	m.notificationHandler.Mute(until)
+	return fmt.Sprintf("🔕 通知已静音至 %s（Cookie 失效告警仍会发送）", until.Format(utils.DateTimeShort))
+}
+
+// CookieStatus 实现 chat.Backend 接口
+func (m *Monitor) CookieStatus() string {
+	return describeCookie(m.cookieManager)
+}
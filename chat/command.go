@@ -0,0 +1,137 @@
+package chat
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backend 聊天命令的数据来源
+type Backend interface {
+	Status() string
+	Check() string
+	History(limit int) string
+	Mute(d time.Duration) string
+	CookieStatus() string
+}
+
+// 命令参数默认值
+const (
+	DefaultHistoryLimit = 5
+	MaxHistoryLimit     = 50
+)
+
+// HelpText 命令帮助信息
+const HelpText = "可用命令:\n" +
+	"/status - 查看订单当前状态\n" +
+	"/check - 立即检查一次交付时间\n" +
+	"/history [N] - 查看最近 N 条检查记录 (默认 5)\n" +
+	"/mute <时长> - 暂停通知，例如 /mute 12h，/mute 0 取消\n" +
+	"/cookie - 查看 Cookie 状态\n" +
+	"/help - 显示本帮助"
+
+// Dispatcher 聊天命令分发器
+type Dispatcher struct {
+	backend Backend
+
+	mu      sync.RWMutex
+	allowed map[string]bool // 允许发送命令的会话 ID
+}
+
+// NewDispatcher 创建命令分发器
+func NewDispatcher(backend Backend, allowedChatIDs []string) *Dispatcher {
+	d := &Dispatcher{backend: backend}
+	d.SetAllowed(allowedChatIDs)
+	return d
+}
+
+// SetAllowed 更新会话 ID 白名单
+func (d *Dispatcher) SetAllowed(allowedChatIDs []string) {
+	allowed := make(map[string]bool, len(allowedChatIDs))
+	for _, id := range allowedChatIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			allowed[id] = true
+		}
+	}
+
+	d.mu.Lock()
+	d.allowed = allowed
+	d.mu.Unlock()
+}
+
+// IsAllowed 检查会话 ID 是否在白名单中
+func (d *Dispatcher) IsAllowed(chatID string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.allowed[chatID]
+}
+
+// Handle 处理一条聊天消息，返回回复内容
+// 会话不在白名单中时返回 false，调用方不应回复
+func (d *Dispatcher) Handle(chatID, text string) (string, bool) {
+	if !d.IsAllowed(chatID) {
+		log.Printf("[Chat] 拒绝未授权会话的命令: chat_id=%s", chatID)
+		return "", false
+	}
+
+	fields := strings.Fields(strings.TrimSpace(text))
+	if len(fields) == 0 {
+		return HelpText, true
+	}
+
+	// 兼容 Telegram 群组中的 /command@botname 形式
+	command := strings.ToLower(fields[0])
+	if i := strings.Index(command, "@"); i > 0 {
+		command = command[:i]
+	}
+	args := fields[1:]
+
+	log.Printf("[Chat] 收到命令: chat_id=%s, command=%s", chatID, command)
+
+	switch command {
+	case "/status":
+		return d.backend.Status(), true
+	case "/check":
+		return d.backend.Check(), true
+	case "/history":
+		limit, err := parseHistoryLimit(args)
+		if err != nil {
+			return err.Error(), true
+		}
+		return d.backend.History(limit), true
+	case "/mute":
+		if len(args) == 0 {
+			return "用法: /mute <时长>，例如 /mute 12h、/mute 30m，/mute 0 取消静音", true
+		}
+		duration, err := time.ParseDuration(args[0])
+		if err != nil || duration < 0 {
+			return fmt.Sprintf("无法解析时长: %s", args[0]), true
+		}
+		return d.backend.Mute(duration), true
+	case "/unmute":
+		return d.backend.Mute(0), true
+	case "/cookie":
+		return d.backend.CookieStatus(), true
+	default:
+		return HelpText, true
+	}
+}
+
+// parseHistoryLimit 解析 /history 的条数参数
+func parseHistoryLimit(args []string) (int, error) {
+	if len(args) == 0 {
+		return DefaultHistoryLimit, nil
+	}
+
+	limit, err := strconv.Atoi(args[0])
+	if err != nil || limit <= 0 {
+		return 0, fmt.Errorf("无效的条数: %s", args[0])
+	}
+	if limit > MaxHistoryLimit {
+		limit = MaxHistoryLimit
+	}
+	return limit, nil
+}
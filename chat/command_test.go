@@ -0,0 +1,114 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBackend 记录收到的调用并返回固定内容
+type fakeBackend struct {
+	mu      sync.Mutex
+	calls   []string
+	history int
+	mute    time.Duration
+}
+
+func (b *fakeBackend) record(call string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls = append(b.calls, call)
+}
+
+func (b *fakeBackend) Status() string { b.record("status"); return "status-reply" }
+func (b *fakeBackend) Check() string  { b.record("check"); return "check-reply" }
+func (b *fakeBackend) History(limit int) string {
+	b.record("history")
+	b.history = limit
+	return fmt.Sprintf("history-%d", limit)
+}
+func (b *fakeBackend) Mute(d time.Duration) string {
+	b.record("mute")
+	b.mute = d
+	return "mute-" + d.String()
+}
+func (b *fakeBackend) CookieStatus() string { b.record("cookie"); return "cookie-reply" }
+
+func TestDispatcherRejectsUnknownChat(t *testing.T) {
+	backend := &fakeBackend{}
+	d := NewDispatcher(backend, []string{"100"})
+
+	reply, ok := d.Handle("200", "/status")
+	if ok || reply != "" {
+		t.Fatalf("Handle() from unknown chat = %q, %v; want no reply", reply, ok)
+	}
+	if len(backend.calls) != 0 {
+		t.Fatalf("backend called for unknown chat: %v", backend.calls)
+	}
+}
+
+func TestDispatcherCommands(t *testing.T) {
+	tests := []struct {
+		text  string
+		reply string
+	}{
+		{"/status", "status-reply"},
+		{"  /STATUS  ", "status-reply"},
+		{"/status@lixiang_bot", "status-reply"},
+		{"/check", "check-reply"},
+		{"/history", fmt.Sprintf("history-%d", DefaultHistoryLimit)},
+		{"/history 3", "history-3"},
+		{"/history 1000", fmt.Sprintf("history-%d", MaxHistoryLimit)},
+		{"/history 0", "无效的条数: 0"},
+		{"/history abc", "无效的条数: abc"},
+		{"/mute 12h", "mute-12h0m0s"},
+		{"/mute 0", "mute-0s"},
+		{"/mute -1h", "无法解析时长: -1h"},
+		{"/mute soon", "无法解析时长: soon"},
+		{"/unmute", "mute-0s"},
+		{"/cookie", "cookie-reply"},
+		{"", HelpText},
+		{"/help", HelpText},
+		{"hello", HelpText},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			d := NewDispatcher(&fakeBackend{}, []string{" 100 ", ""})
+			reply, ok := d.Handle("100", tt.text)
+			if !ok {
+				t.Fatalf("Handle(%q) rejected an allowed chat", tt.text)
+			}
+			if reply != tt.reply {
+				t.Errorf("Handle(%q) = %q, want %q", tt.text, reply, tt.reply)
+			}
+		})
+	}
+}
+
+func TestDispatcherMuteUsage(t *testing.T) {
+	backend := &fakeBackend{}
+	d := NewDispatcher(backend, []string{"100"})
+
+	reply, _ := d.Handle("100", "/mute")
+	if !strings.HasPrefix(reply, "用法: /mute") {
+		t.Errorf("Handle(/mute) = %q, want usage", reply)
+	}
+	if len(backend.calls) != 0 {
+		t.Errorf("backend called without a duration: %v", backend.calls)
+	}
+}
+
+func TestDispatcherSetAllowed(t *testing.T) {
+	d := NewDispatcher(&fakeBackend{}, []string{"100"})
+	d.SetAllowed([]string{"200"})
+
+	if d.IsAllowed("100") {
+		t.Error("chat 100 still allowed after SetAllowed")
+	}
+	if !d.IsAllowed("200") {
+		t.Error("chat 200 not allowed after SetAllowed")
+	}
+}
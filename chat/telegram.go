@@ -0,0 +1,192 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTelegramAPIBase Telegram Bot API 默认地址
+const DefaultTelegramAPIBase = "https://api.telegram.org"
+
+// telegramPollTimeout 长轮询超时时间（秒）
+const telegramPollTimeout = 30
+
+// TelegramBot 通过 getUpdates 长轮询接收 Telegram 命令
+type TelegramBot struct {
+	Token      string
+	APIBase    string // 可指向本地模拟的 Bot API，便于测试
+	dispatcher *Dispatcher
+	client     *http.Client
+	offset     int64
+	stop       chan struct{}
+	done       chan struct{}
+	ctx        context.Context // 用于中断正在进行的长轮询
+	cancel     context.CancelFunc
+}
+
+// telegramUpdate Telegram 更新
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		Text string `json:"text"`
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+	} `json:"message"`
+}
+
+// telegramResponse Telegram API 响应
+type telegramResponse struct {
+	OK          bool             `json:"ok"`
+	Description string           `json:"description"`
+	Result      []telegramUpdate `json:"result"`
+}
+
+// NewTelegramBot 创建 Telegram 机器人
+func NewTelegramBot(token, apiBase string, dispatcher *Dispatcher) *TelegramBot {
+	if apiBase == "" {
+		apiBase = DefaultTelegramAPIBase
+	}
+
+	return &TelegramBot{
+		Token:      token,
+		APIBase:    strings.TrimRight(apiBase, "/"),
+		dispatcher: dispatcher,
+		client: &http.Client{
+			Timeout: (telegramPollTimeout + 10) * time.Second,
+		},
+	}
+}
+
+// Start 在后台开始轮询
+func (b *TelegramBot) Start() {
+	b.stop = make(chan struct{})
+	b.done = make(chan struct{})
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+
+	go func() {
+		defer close(b.done)
+		log.Println("[Chat] Telegram 命令轮询已启动")
+
+		for {
+			select {
+			case <-b.stop:
+				return
+			default:
+			}
+
+			if err := b.poll(); err != nil {
+				if b.ctx.Err() != nil {
+					return
+				}
+				log.Printf("[Chat] Telegram 轮询失败: %v", err)
+				select {
+				case <-b.stop:
+					return
+				case <-time.After(5 * time.Second):
+				}
+			}
+		}
+	}()
+}
+
+// Stop 停止轮询
+// 正在进行的长轮询请求会被立即中断，正在处理的命令会等待其完成
+func (b *TelegramBot) Stop() {
+	if b.stop == nil {
+		return
+	}
+	close(b.stop)
+	b.cancel()
+	<-b.done
+	b.stop = nil
+	log.Println("[Chat] Telegram 命令轮询已停止")
+}
+
+// poll 拉取一批更新并逐条处理
+func (b *TelegramBot) poll() error {
+	params := url.Values{}
+	params.Set("timeout", strconv.Itoa(telegramPollTimeout))
+	params.Set("offset", strconv.FormatInt(b.offset, 10))
+	params.Set("allowed_updates", `["message"]`)
+
+	req, err := http.NewRequestWithContext(b.ctx, http.MethodGet, b.methodURL("getUpdates")+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求 getUpdates 失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %v", err)
+	}
+
+	var result telegramResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	if !result.OK {
+		return fmt.Errorf("Telegram 返回错误: %s", result.Description)
+	}
+
+	for _, update := range result.Result {
+		b.offset = update.UpdateID + 1
+		if update.Message == nil || update.Message.Text == "" {
+			continue
+		}
+
+		chatID := strconv.FormatInt(update.Message.Chat.ID, 10)
+		reply, ok := b.dispatcher.Handle(chatID, update.Message.Text)
+		if !ok {
+			continue
+		}
+
+		if err := b.sendMessage(update.Message.Chat.ID, reply); err != nil {
+			log.Printf("[Chat] Telegram 回复失败: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// sendMessage 发送回复消息
+func (b *TelegramBot) sendMessage(chatID int64, text string) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"chat_id": chatID,
+		"text":    text,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化消息失败: %v", err)
+	}
+
+	resp, err := b.client.Post(b.methodURL("sendMessage"), "application/json", bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("请求 sendMessage 失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("sendMessage 返回错误状态码: %d, 响应: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// methodURL 构建 Bot API 方法地址
+func (b *TelegramBot) methodURL(method string) string {
+	return fmt.Sprintf("%s/bot%s/%s", b.APIBase, b.Token, method)
+}
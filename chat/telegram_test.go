@@ -0,0 +1,118 @@
+package chat
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTelegram 模拟 Telegram Bot API：第一次 getUpdates 返回预设的更新，之后的长轮询一直挂起直到请求被取消
+type fakeTelegram struct {
+	mu      sync.Mutex
+	offsets []string
+	sent    []map[string]interface{}
+	polled  chan struct{} // 第二次 getUpdates 到达时关闭
+	updates string
+}
+
+func (f *fakeTelegram) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/botTOKEN/getUpdates":
+		f.mu.Lock()
+		f.offsets = append(f.offsets, r.URL.Query().Get("offset"))
+		n := len(f.offsets)
+		f.mu.Unlock()
+
+		if n == 1 {
+			w.Write([]byte(f.updates))
+			return
+		}
+		if n == 2 {
+			close(f.polled)
+		}
+		<-r.Context().Done()
+
+	case "/botTOKEN/sendMessage":
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.sent = append(f.sent, payload)
+		f.mu.Unlock()
+		w.Write([]byte(`{"ok":true}`))
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestTelegramBotPollsAndReplies(t *testing.T) {
+	fake := &fakeTelegram{
+		polled: make(chan struct{}),
+		updates: `{"ok":true,"result":[
+			{"update_id":10,"message":{"text":"/status","chat":{"id":100}}},
+			{"update_id":11,"message":{"text":"/check","chat":{"id":200}}},
+			{"update_id":12}
+		]}`,
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	backend := &fakeBackend{}
+	bot := NewTelegramBot("TOKEN", server.URL+"/", NewDispatcher(backend, []string{"100"}))
+	bot.Start()
+
+	select {
+	case <-fake.polled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("bot did not poll again after handling the first batch")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		bot.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop() did not interrupt the pending long poll")
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	if len(fake.offsets) < 2 || fake.offsets[0] != "0" || fake.offsets[1] != "13" {
+		t.Errorf("getUpdates offsets = %v, want [0 13 ...]", fake.offsets)
+	}
+	if len(fake.sent) != 1 {
+		t.Fatalf("sent %d messages, want 1 (unauthorized chat must be ignored): %v", len(fake.sent), fake.sent)
+	}
+	if got := fake.sent[0]["chat_id"]; got != float64(100) {
+		t.Errorf("reply chat_id = %v, want 100", got)
+	}
+	if got := fake.sent[0]["text"]; got != "status-reply" {
+		t.Errorf("reply text = %v, want status-reply", got)
+	}
+	if len(backend.calls) != 1 || backend.calls[0] != "status" {
+		t.Errorf("backend calls = %v, want [status]", backend.calls)
+	}
+}
+
+func TestTelegramBotAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":false,"description":"Unauthorized"}`))
+	}))
+	defer server.Close()
+
+	bot := NewTelegramBot("TOKEN", server.URL, NewDispatcher(&fakeBackend{}, nil))
+	bot.ctx = t.Context()
+	if err := bot.poll(); err == nil {
+		t.Fatal("poll() returned nil for ok=false response")
+	}
+}
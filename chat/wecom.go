@@ -0,0 +1,392 @@
+package chat
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// wecomMaxClockSkew 回调请求 timestamp 与本机时间允许的最大偏差，超出时视为重放的旧请求
+const wecomMaxClockSkew = 5 * time.Minute
+
+// wecomReplyTimeout 未配置应用消息接口时等待命令结果的时长
+// 企业微信 5 秒内收不到响应会重试回调，超时后先被动回复提示，命令在后台继续执行
+const wecomReplyTimeout = 4 * time.Second
+
+// wecomSeenMsgIDs 记录最近处理过的消息 ID 数量，用于忽略企业微信的重试回调
+const wecomSeenMsgIDs = 256
+
+// wecomPendingReply 命令未能在被动回复时限内完成时的提示
+const wecomPendingReply = "⏳ 命令仍在执行，稍后发送 /status 查看结果"
+
+// WeComCallback 企业微信自建应用的消息回调处理器
+// 接收应用消息中的命令；配置了应用消息接口时立即返回空响应并主动发送结果，否则以被动回复的方式返回结果
+type WeComCallback struct {
+	token        string
+	corpID       string
+	aesKey       []byte
+	dispatcher   *Dispatcher
+	app          *WeComApp
+	replyTimeout time.Duration
+	seen         *msgIDCache
+	wg           sync.WaitGroup // 后台执行中的命令
+}
+
+// wecomEnvelope 加密消息外层结构
+type wecomEnvelope struct {
+	XMLName    xml.Name `xml:"xml"`
+	ToUserName string   `xml:"ToUserName"`
+	Encrypt    string   `xml:"Encrypt"`
+}
+
+// wecomMessage 解密后的消息
+type wecomMessage struct {
+	XMLName      xml.Name `xml:"xml"`
+	ToUserName   string   `xml:"ToUserName"`
+	FromUserName string   `xml:"FromUserName"`
+	MsgType      string   `xml:"MsgType"`
+	Content      string   `xml:"Content"`
+	MsgID        string   `xml:"MsgId"`
+}
+
+// wecomReply 加密后的被动回复
+type wecomReply struct {
+	XMLName      xml.Name `xml:"xml"`
+	Encrypt      string   `xml:"Encrypt"`
+	MsgSignature string   `xml:"MsgSignature"`
+	TimeStamp    string   `xml:"TimeStamp"`
+	Nonce        string   `xml:"Nonce"`
+}
+
+// NewWeComCallback 创建企业微信回调处理器
+// encodingAESKey 为应用回调配置中的 43 位 EncodingAESKey
+func NewWeComCallback(token, encodingAESKey, corpID string, dispatcher *Dispatcher) (*WeComCallback, error) {
+	aesKey, err := base64.StdEncoding.DecodeString(encodingAESKey + "=")
+	if err != nil || len(aesKey) != 32 {
+		return nil, fmt.Errorf("EncodingAESKey 无效")
+	}
+
+	return &WeComCallback{
+		token:        token,
+		corpID:       corpID,
+		aesKey:       aesKey,
+		dispatcher:   dispatcher,
+		replyTimeout: wecomReplyTimeout,
+		seen:         newMsgIDCache(wecomSeenMsgIDs),
+	}, nil
+}
+
+// SetApp 设置应用消息发送器，设置后命令结果通过应用消息主动发送
+func (c *WeComCallback) SetApp(app *WeComApp) {
+	c.app = app
+}
+
+// Wait 等待后台执行中的命令完成，ctx 结束时返回其错误
+func (c *WeComCallback) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ServeHTTP 实现 http.Handler 接口
+func (c *WeComCallback) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	signature := query.Get("msg_signature")
+	timestamp := query.Get("timestamp")
+	nonce := query.Get("nonce")
+
+	if !validTimestamp(timestamp, time.Now()) {
+		http.Error(w, "stale timestamp", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		// 回调地址验证
+		echo := query.Get("echostr")
+		if !c.validSignature(signature, timestamp, nonce, echo) {
+			http.Error(w, "invalid signature", http.StatusForbidden)
+			return
+		}
+		plain, err := c.decrypt(echo)
+		if err != nil {
+			http.Error(w, "decrypt failed", http.StatusBadRequest)
+			return
+		}
+		w.Write(plain)
+
+	case http.MethodPost:
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, "read body failed", http.StatusBadRequest)
+			return
+		}
+
+		var envelope wecomEnvelope
+		if err := xml.Unmarshal(body, &envelope); err != nil {
+			http.Error(w, "invalid xml", http.StatusBadRequest)
+			return
+		}
+		if !c.validSignature(signature, timestamp, nonce, envelope.Encrypt) {
+			http.Error(w, "invalid signature", http.StatusForbidden)
+			return
+		}
+
+		plain, err := c.decrypt(envelope.Encrypt)
+		if err != nil {
+			http.Error(w, "decrypt failed", http.StatusBadRequest)
+			return
+		}
+
+		var msg wecomMessage
+		if err := xml.Unmarshal(plain, &msg); err != nil {
+			http.Error(w, "invalid message", http.StatusBadRequest)
+			return
+		}
+
+		// 只处理文本消息，其余消息直接返回空响应
+		if msg.MsgType != "text" {
+			return
+		}
+
+		// 企业微信未及时收到响应时会用相同的 MsgId 重试，同一条消息只执行一次
+		if msg.MsgID != "" && c.seen.Seen(msg.MsgID) {
+			return
+		}
+
+		reply, ok := c.handle(msg.FromUserName, msg.Content)
+		if !ok {
+			return
+		}
+
+		out, err := c.buildReply(msg.FromUserName, reply, nonce)
+		if err != nil {
+			log.Printf("[Chat] 企业微信回复加密失败: %v", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.Write(out)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handle 在后台执行命令，返回需要被动回复的内容
+// 配置了应用消息接口时结果由后台主动发送，不被动回复；否则最多等待 replyTimeout
+func (c *WeComCallback) handle(user, text string) (string, bool) {
+	result := make(chan string, 1)
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		reply, ok := c.dispatcher.Handle(user, text)
+		if !ok {
+			reply = ""
+		}
+
+		if c.app == nil {
+			result <- reply
+			return
+		}
+		if reply == "" {
+			return
+		}
+		if err := c.app.SendText(user, reply); err != nil {
+			log.Printf("[Chat] 企业微信应用消息发送失败: %v", err)
+		}
+	}()
+
+	if c.app != nil {
+		return "", false
+	}
+
+	select {
+	case reply := <-result:
+		return reply, reply != ""
+	case <-time.After(c.replyTimeout):
+		log.Printf("[Chat] 企业微信命令未能在 %s 内完成，结果将无法回复: %s", c.replyTimeout, text)
+		return wecomPendingReply, true
+	}
+}
+
+// validSignature 以常量时间比较回调签名
+func (c *WeComCallback) validSignature(signature, timestamp, nonce, encrypted string) bool {
+	expected := c.signature(timestamp, nonce, encrypted)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(signature)) == 1
+}
+
+// validTimestamp 检查回调请求的 timestamp 是否在允许的时间偏差内
+func validTimestamp(timestamp string, now time.Time) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	skew := now.Sub(time.Unix(ts, 0))
+	return skew <= wecomMaxClockSkew && skew >= -wecomMaxClockSkew
+}
+
+// buildReply 构建加密的被动回复
+func (c *WeComCallback) buildReply(toUser, content, nonce string) ([]byte, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	plain := fmt.Sprintf("<xml><ToUserName><![CDATA[%s]]></ToUserName>"+
+		"<FromUserName><![CDATA[%s]]></FromUserName>"+
+		"<CreateTime>%s</CreateTime>"+
+		"<MsgType><![CDATA[text]]></MsgType>"+
+		"<Content><![CDATA[%s]]></Content></xml>",
+		toUser, c.corpID, timestamp, strings.ReplaceAll(content, "]]>", "]]]]><![CDATA[>"))
+
+	encrypted, err := c.encrypt([]byte(plain))
+	if err != nil {
+		return nil, err
+	}
+
+	return xml.Marshal(wecomReply{
+		Encrypt:      encrypted,
+		MsgSignature: c.signature(timestamp, nonce, encrypted),
+		TimeStamp:    timestamp,
+		Nonce:        nonce,
+	})
+}
+
+// signature 计算消息签名
+func (c *WeComCallback) signature(timestamp, nonce, encrypted string) string {
+	parts := []string{c.token, timestamp, nonce, encrypted}
+	sort.Strings(parts)
+	sum := sha1.Sum([]byte(strings.Join(parts, "")))
+	return hex.EncodeToString(sum[:])
+}
+
+// decrypt 解密消息：random(16) + msg_len(4) + msg + corp_id
+func (c *WeComCallback) decrypt(encrypted string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("base64 解码失败: %v", err)
+	}
+
+	block, err := aes.NewCipher(c.aesKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("密文长度无效")
+	}
+
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, c.aesKey[:aes.BlockSize]).CryptBlocks(plain, data)
+
+	// 去除 PKCS#7 填充（企业微信使用 32 字节块）
+	pad := int(plain[len(plain)-1])
+	if pad < 1 || pad > 32 || pad > len(plain) {
+		return nil, fmt.Errorf("填充无效")
+	}
+	plain = plain[:len(plain)-pad]
+
+	if len(plain) < 20 {
+		return nil, fmt.Errorf("明文长度无效")
+	}
+	msgLen := int(binary.BigEndian.Uint32(plain[16:20]))
+	if 20+msgLen > len(plain) {
+		return nil, fmt.Errorf("消息长度无效")
+	}
+
+	msg := plain[20 : 20+msgLen]
+	if receiver := string(plain[20+msgLen:]); c.corpID != "" && receiver != c.corpID {
+		return nil, fmt.Errorf("CorpID 不匹配")
+	}
+
+	return msg, nil
+}
+
+// encrypt 加密消息
+func (c *WeComCallback) encrypt(msg []byte) (string, error) {
+	var buf bytes.Buffer
+
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	buf.Write(random)
+
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(msg)))
+	buf.Write(length)
+	buf.Write(msg)
+	buf.WriteString(c.corpID)
+
+	// PKCS#7 填充到 32 字节的整数倍
+	pad := 32 - buf.Len()%32
+	buf.Write(bytes.Repeat([]byte{byte(pad)}, pad))
+
+	block, err := aes.NewCipher(c.aesKey)
+	if err != nil {
+		return "", err
+	}
+
+	data := buf.Bytes()
+	cipher.NewCBCEncrypter(block, c.aesKey[:aes.BlockSize]).CryptBlocks(data, data)
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// msgIDCache 最近处理过的消息 ID（LRU），容量满时淘汰最久未出现的记录
+type msgIDCache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[string]*list.Element
+}
+
+// newMsgIDCache 创建容量为 size 的消息 ID 缓存
+func newMsgIDCache(size int) *msgIDCache {
+	return &msgIDCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Seen 报告消息 ID 是否出现过，未出现过时记录下来
+func (c *msgIDCache) Seen(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[id]; ok {
+		c.order.MoveToFront(elem)
+		return true
+	}
+
+	c.items[id] = c.order.PushFront(id)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(string))
+	}
+	return false
+}
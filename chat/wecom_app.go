@@ -0,0 +1,160 @@
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultWeComAPIBase 企业微信服务端 API 默认地址
+const DefaultWeComAPIBase = "https://qyapi.weixin.qq.com"
+
+// 企业微信 access_token 无效或过期的错误码，遇到时重新获取一次
+const (
+	wecomErrInvalidToken = 40014
+	wecomErrExpiredToken = 42001
+)
+
+// WeComApp 企业微信自建应用的消息发送接口
+// 回调只能在 5 秒内被动回复，执行较慢的命令（如 /check）时通过应用消息主动发送结果
+type WeComApp struct {
+	CorpID  string
+	Secret  string
+	AgentID int
+	APIBase string // 可指向本地模拟的接口，便于测试
+	client  *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// wecomAPIResponse 企业微信接口响应
+type wecomAPIResponse struct {
+	ErrCode     int    `json:"errcode"`
+	ErrMsg      string `json:"errmsg"`
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// NewWeComApp 创建企业微信应用消息发送器
+func NewWeComApp(corpID, secret string, agentID int, apiBase string) *WeComApp {
+	if apiBase == "" {
+		apiBase = DefaultWeComAPIBase
+	}
+
+	return &WeComApp{
+		CorpID:  corpID,
+		Secret:  secret,
+		AgentID: agentID,
+		APIBase: strings.TrimRight(apiBase, "/"),
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// SendText 向成员发送文本消息
+func (a *WeComApp) SendText(toUser, content string) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"touser":  toUser,
+		"msgtype": "text",
+		"agentid": a.AgentID,
+		"text": map[string]string{
+			"content": content,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("序列化消息失败: %v", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		token, err := a.token()
+		if err != nil {
+			return err
+		}
+
+		var result wecomAPIResponse
+		if err := a.call(http.MethodPost, "/cgi-bin/message/send?access_token="+url.QueryEscape(token), payload, &result); err != nil {
+			return fmt.Errorf("请求 message/send 失败: %v", err)
+		}
+
+		// access_token 在有效期内被重置时重新获取一次
+		if (result.ErrCode == wecomErrInvalidToken || result.ErrCode == wecomErrExpiredToken) && attempt == 0 {
+			a.resetToken()
+			continue
+		}
+		if result.ErrCode != 0 {
+			return fmt.Errorf("企业微信返回错误: %d %s", result.ErrCode, result.ErrMsg)
+		}
+		return nil
+	}
+}
+
+// token 返回缓存的 access_token，过期前 5 分钟重新获取
+func (a *WeComApp) token() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.accessToken != "" && time.Now().Before(a.expiresAt) {
+		return a.accessToken, nil
+	}
+
+	params := url.Values{}
+	params.Set("corpid", a.CorpID)
+	params.Set("corpsecret", a.Secret)
+
+	var result wecomAPIResponse
+	if err := a.call(http.MethodGet, "/cgi-bin/gettoken?"+params.Encode(), nil, &result); err != nil {
+		return "", fmt.Errorf("请求 gettoken 失败: %v", err)
+	}
+	if result.ErrCode != 0 || result.AccessToken == "" {
+		return "", fmt.Errorf("获取 access_token 失败: %d %s", result.ErrCode, result.ErrMsg)
+	}
+
+	a.accessToken = result.AccessToken
+	a.expiresAt = time.Now().Add(time.Duration(result.ExpiresIn)*time.Second - 5*time.Minute)
+	return a.accessToken, nil
+}
+
+// resetToken 丢弃缓存的 access_token
+func (a *WeComApp) resetToken() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.accessToken = ""
+}
+
+// call 调用企业微信接口并解析 JSON 响应
+func (a *WeComApp) call(method, path string, payload []byte, result *wecomAPIResponse) error {
+	req, err := http.NewRequest(method, a.APIBase+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("返回错误状态码: %d, 响应: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	return nil
+}
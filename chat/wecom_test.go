@@ -0,0 +1,274 @@
+package chat
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testCorpID = "ww0123456789"
+
+// newTestWeComCallback 创建使用固定密钥的回调处理器
+func newTestWeComCallback(t *testing.T, backend Backend) *WeComCallback {
+	t.Helper()
+	key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	callback, err := NewWeComCallback("token", strings.TrimSuffix(key, "="), testCorpID, NewDispatcher(backend, []string{"zhangsan"}))
+	if err != nil {
+		t.Fatalf("NewWeComCallback() error = %v", err)
+	}
+	return callback
+}
+
+// postMessage 以企业微信服务器的方式加密、签名并投递一条文本消息
+func postMessage(t *testing.T, c *WeComCallback, from, content string, timestamp time.Time, tamper bool) *httptest.ResponseRecorder {
+	t.Helper()
+	return postMessageWithID(t, c, "", from, content, timestamp, tamper)
+}
+
+// postMessageWithID 投递一条带 MsgId 的文本消息
+func postMessageWithID(t *testing.T, c *WeComCallback, msgID, from, content string, timestamp time.Time, tamper bool) *httptest.ResponseRecorder {
+	t.Helper()
+	plain := "<xml><ToUserName><![CDATA[" + testCorpID + "]]></ToUserName>" +
+		"<FromUserName><![CDATA[" + from + "]]></FromUserName>" +
+		"<MsgType><![CDATA[text]]></MsgType>" +
+		"<Content><![CDATA[" + content + "]]></Content>" +
+		"<MsgId>" + msgID + "</MsgId></xml>"
+	encrypted, err := c.encrypt([]byte(plain))
+	if err != nil {
+		t.Fatalf("encrypt() error = %v", err)
+	}
+
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	signature := c.signature(ts, "nonce", encrypted)
+	if tamper {
+		signature = strings.Repeat("0", len(signature))
+	}
+	query := url.Values{"msg_signature": {signature}, "timestamp": {ts}, "nonce": {"nonce"}}
+	body := "<xml><ToUserName><![CDATA[" + testCorpID + "]]></ToUserName><Encrypt><![CDATA[" + encrypted + "]]></Encrypt></xml>"
+
+	req := httptest.NewRequest(http.MethodPost, "/api/chat/wecom?"+query.Encode(), strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestWeComCallbackReply(t *testing.T) {
+	backend := &fakeBackend{}
+	c := newTestWeComCallback(t, backend)
+
+	rec := postMessage(t, c, "zhangsan", "/status", time.Now(), false)
+	msg := decryptReply(t, c, rec)
+	if msg.ToUserName != "zhangsan" || msg.Content != "status-reply" {
+		t.Errorf("reply = to %q content %q, want to zhangsan content status-reply", msg.ToUserName, msg.Content)
+	}
+}
+
+// decryptReply 校验并解密被动回复
+func decryptReply(t *testing.T, c *WeComCallback, rec *httptest.ResponseRecorder) wecomMessage {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	var reply wecomReply
+	if err := xml.Unmarshal(rec.Body.Bytes(), &reply); err != nil {
+		t.Fatalf("reply is not valid xml: %v", err)
+	}
+	if reply.MsgSignature != c.signature(reply.TimeStamp, reply.Nonce, reply.Encrypt) {
+		t.Error("reply signature does not match")
+	}
+	plain, err := c.decrypt(reply.Encrypt)
+	if err != nil {
+		t.Fatalf("decrypt(reply) error = %v", err)
+	}
+	var msg wecomMessage
+	if err := xml.Unmarshal(plain, &msg); err != nil {
+		t.Fatalf("decrypted reply is not valid xml: %v", err)
+	}
+	return msg
+}
+
+func TestWeComCallbackIgnoresRetriedMsgID(t *testing.T) {
+	backend := &fakeBackend{}
+	c := newTestWeComCallback(t, backend)
+
+	first := postMessageWithID(t, c, "1001", "zhangsan", "/status", time.Now(), false)
+	if msg := decryptReply(t, c, first); msg.Content != "status-reply" {
+		t.Errorf("first reply = %q, want status-reply", msg.Content)
+	}
+
+	// 企业微信重试回调时使用相同的 MsgId
+	retry := postMessageWithID(t, c, "1001", "zhangsan", "/status", time.Now(), false)
+	if retry.Code != http.StatusOK || retry.Body.Len() != 0 {
+		t.Errorf("retry = %d %q, want an empty 200", retry.Code, retry.Body.String())
+	}
+	postMessageWithID(t, c, "1002", "zhangsan", "/status", time.Now(), false)
+	if len(backend.calls) != 2 {
+		t.Errorf("backend calls = %v, want 2 status calls", backend.calls)
+	}
+}
+
+func TestMsgIDCacheEvictsLeastRecent(t *testing.T) {
+	cache := newMsgIDCache(2)
+	cache.Seen("a")
+	cache.Seen("b")
+	if !cache.Seen("a") {
+		t.Fatal("a not remembered")
+	}
+	cache.Seen("c") // 淘汰最久未出现的 b
+	if cache.Seen("b") {
+		t.Error("b still remembered after eviction")
+	}
+	if !cache.Seen("c") {
+		t.Error("c not remembered")
+	}
+}
+
+// slowBackend 的 /check 阻塞到 release 关闭
+type slowBackend struct {
+	fakeBackend
+	release chan struct{}
+}
+
+func (b *slowBackend) Check() string {
+	<-b.release
+	return b.fakeBackend.Check()
+}
+
+func TestWeComCallbackSlowCommandWithoutApp(t *testing.T) {
+	backend := &slowBackend{release: make(chan struct{})}
+	c := newTestWeComCallback(t, backend)
+	c.replyTimeout = 20 * time.Millisecond
+
+	rec := postMessage(t, c, "zhangsan", "/check", time.Now(), false)
+	if msg := decryptReply(t, c, rec); msg.Content != wecomPendingReply {
+		t.Errorf("reply = %q, want the pending notice", msg.Content)
+	}
+
+	close(backend.release)
+	if err := c.Wait(t.Context()); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if len(backend.calls) != 1 || backend.calls[0] != "check" {
+		t.Errorf("backend calls = %v, want the check to finish in the background", backend.calls)
+	}
+}
+
+func TestWeComCallbackSendsResultThroughApp(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sent []map[string]interface{}
+	)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cgi-bin/gettoken":
+			if r.URL.Query().Get("corpsecret") != "secret" {
+				w.Write([]byte(`{"errcode":40001,"errmsg":"invalid credential"}`))
+				return
+			}
+			w.Write([]byte(`{"errcode":0,"access_token":"access","expires_in":7200}`))
+		case "/cgi-bin/message/send":
+			if r.URL.Query().Get("access_token") != "access" {
+				w.Write([]byte(`{"errcode":40014,"errmsg":"invalid access_token"}`))
+				return
+			}
+			var payload map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&payload)
+			mu.Lock()
+			sent = append(sent, payload)
+			mu.Unlock()
+			w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer api.Close()
+
+	backend := &slowBackend{release: make(chan struct{})}
+	c := newTestWeComCallback(t, backend)
+	c.SetApp(NewWeComApp(testCorpID, "secret", 1000002, api.URL))
+
+	// 立即返回空响应，不等待 /check 完成
+	rec := postMessage(t, c, "zhangsan", "/check", time.Now(), false)
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Fatalf("response = %d %q, want an empty 200", rec.Code, rec.Body.String())
+	}
+
+	close(backend.release)
+	if err := c.Wait(t.Context()); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d app messages, want 1", len(sent))
+	}
+	text, _ := sent[0]["text"].(map[string]interface{})
+	if sent[0]["touser"] != "zhangsan" || sent[0]["agentid"] != float64(1000002) || text["content"] != "check-reply" {
+		t.Errorf("app message = %v", sent[0])
+	}
+}
+
+func TestWeComCallbackRejects(t *testing.T) {
+	tests := []struct {
+		name      string
+		from      string
+		timestamp time.Time
+		tamper    bool
+		code      int
+	}{
+		{"bad signature", "zhangsan", time.Now(), true, http.StatusForbidden},
+		{"stale timestamp", "zhangsan", time.Now().Add(-10 * time.Minute), false, http.StatusForbidden},
+		{"future timestamp", "zhangsan", time.Now().Add(10 * time.Minute), false, http.StatusForbidden},
+		{"unknown user", "lisi", time.Now(), false, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &fakeBackend{}
+			c := newTestWeComCallback(t, backend)
+
+			rec := postMessage(t, c, tt.from, "/status", tt.timestamp, tt.tamper)
+			if rec.Code != tt.code {
+				t.Errorf("status = %d, want %d", rec.Code, tt.code)
+			}
+			if len(backend.calls) != 0 {
+				t.Errorf("backend called: %v", backend.calls)
+			}
+			if tt.code == http.StatusOK && rec.Body.Len() != 0 {
+				t.Errorf("replied to unknown user: %s", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestWeComCallbackVerifyURL(t *testing.T) {
+	c := newTestWeComCallback(t, &fakeBackend{})
+	echo, err := c.encrypt([]byte("echo-123"))
+	if err != nil {
+		t.Fatalf("encrypt() error = %v", err)
+	}
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	query := url.Values{
+		"msg_signature": {c.signature(ts, "nonce", echo)},
+		"timestamp":     {ts},
+		"nonce":         {"nonce"},
+		"echostr":       {echo},
+	}
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/chat/wecom?"+query.Encode(), nil))
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "echo-123" {
+		t.Errorf("verify = %d %q, want 200 echo-123", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,670 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"lixiang-monitor/cfg"
+	"lixiang-monitor/cookie"
+	"lixiang-monitor/db"
+	"lixiang-monitor/delivery"
+	"lixiang-monitor/export"
+	"lixiang-monitor/notifier"
+	"lixiang-monitor/utils"
+
+	"github.com/robfig/cron/v3"
+	"github.com/spf13/viper"
+)
+
+// 单次检查模式的退出码，供 systemd timer、Kubernetes CronJob 等外部调度器判断结果
+const (
+	exitUnchanged     = 0  // 预计时间未变化
+	exitError         = 1  // 配置或数据库等其他错误
+	exitChanged       = 10 // 预计时间发生变化（含首次检查）
+	exitCookieExpired = 20 // Cookie 已失效
+	exitAPIError      = 30 // 接口请求或解析失败
+)
+
+// command 命令行子命令
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) int
+}
+
+// commands 所有子命令，按帮助信息中的显示顺序排列
+var commands = []command{
+	{"run", "启动监控服务（默认）", runDaemon},
+	{"check", "立即获取一次订单数据并打印，不发送通知", runCheck},
+	{"once", "执行一次完整检查（含通知和记录）后退出，退出码表示检查结果", runOnce},
+	{"history", "打印数据库中的检查记录", runHistory},
+	{"notify-test", "通过每个已配置的通知器发送测试消息", runNotifyTest},
+	{"cookie", "Cookie 管理: status | import", runCookie},
+	{"config", "配置管理: validate | show", runConfig},
+	{"export", "导出检查记录为 JSON、JSON Lines、CSV 或 Excel", runExport},
+	{"import-cookies", "从浏览器导出文件导入 Cookie（同 cookie import）", runImportCookies},
+}
+
+// runCLI 解析全局参数和子命令并执行，返回进程退出码
+func runCLI(args []string) int {
+	global := flag.NewFlagSet("lixiang-monitor", flag.ContinueOnError)
+	configFile := global.String("config", "", "配置文件路径（环境变量 "+cfg.EnvConfigFile+"）")
+	dataDir := global.String("data-dir", "", "数据目录，存放数据库和 Cookie 状态文件（环境变量 "+cfg.EnvDataDir+"）")
+	dbPath := global.String("db", "", "数据库文件路径，默认为数据目录下的 "+cfg.DBFileName+"（环境变量 "+cfg.EnvDBPath+"）")
+	global.Usage = func() { printUsage(global.Output()) }
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	cfg.SetPaths(*configFile, *dataDir, *dbPath)
+
+	args = global.Args()
+	if len(args) == 0 {
+		return runDaemon(nil)
+	}
+
+	name := args[0]
+	if name == "-h" || name == "--help" || name == "help" {
+		printUsage(os.Stdout)
+		return 0
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.run(args[1:])
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "未知命令: %s\n\n", name)
+	printUsage(os.Stderr)
+	return 2
+}
+
+// printUsage 打印命令行帮助信息
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "用法: lixiang-monitor [--config 文件] [--data-dir 目录] [--db 文件] <命令> [参数]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "命令:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.name, cmd.usage)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "全局参数:")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "  --config\t配置文件路径，默认依次搜索 ./、$XDG_CONFIG_HOME/%s、/etc/%s 下的 config.yaml (%s)\n", cfg.AppName, cfg.AppName, cfg.EnvConfigFile)
+	fmt.Fprintf(tw, "  --data-dir\t数据目录，默认 $XDG_DATA_HOME/%s；当前目录已有 config.yaml 或数据库时为当前目录 (%s)\n", cfg.AppName, cfg.EnvDataDir)
+	fmt.Fprintf(tw, "  --db\t数据库文件路径，默认为数据目录下的 %s (%s)\n", cfg.DBFileName, cfg.EnvDBPath)
+	tw.Flush()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "使用 lixiang-monitor <命令> -h 查看命令的参数")
+}
+
+// loadCLIConfig 读取配置文件，供不需要启动监控服务的子命令使用
+func loadCLIConfig() (*cfg.Config, error) {
+	if err := cfg.Init(); err != nil {
+		return nil, err
+	}
+	return cfg.Load()
+}
+
+// checkStartupPaths 启动前检查显式指定的配置文件是否存在，以及数据目录是否可写
+func checkStartupPaths() error {
+	paths := cfg.GetPaths()
+	if paths.ConfigFile != "" {
+		if _, err := os.Stat(paths.ConfigFile); err != nil {
+			return fmt.Errorf("无法读取配置文件 %s: %v", paths.ConfigFile, err)
+		}
+	}
+	if err := cfg.EnsureDataDir(); err != nil {
+		return err
+	}
+
+	log.Printf("数据目录: %s，数据库: %s", paths.DataDir, paths.DBPath)
+	return nil
+}
+
+// runDaemon 启动监控服务
+func runDaemon(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	fs.Parse(args)
+
+	if err := checkStartupPaths(); err != nil {
+		log.Printf("❌ %v", err)
+		return 1
+	}
+
+	// 在 NewMonitor 启动配置监听、Web 服务和聊天命令之前检查，拒绝启动时不留下任何后台任务
+	if err := db.CheckSchema(cfg.GetPaths().DBPath); err != nil {
+		log.Printf("❌ %v", err)
+		return 1
+	}
+
+	monitor := NewMonitor()
+
+	// 检查配置
+	if len(monitor.Notifiers) == 0 {
+		log.Println("警告: 未配置任何通知器，将不会发送通知")
+	}
+
+	if monitor.LixiangCookies == "" {
+		log.Println("警告: 未配置理想汽车 Cookies，可能导致请求失败")
+	}
+
+	// 启动监控
+	if err := monitor.Start(); err != nil {
+		log.Printf("启动监控服务失败: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// runCheck 立即获取一次订单数据并打印
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	fs.Parse(args)
+
+	// 不创建 Monitor：不启动配置监听和 Web 服务，也不创建或迁移数据库
+	config, err := loadCLIConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 加载配置失败: %v\n", err)
+		return 1
+	}
+	manager := newCLICookieManager(config)
+
+	rawData, err := manager.FetchOrderData(config.OrderID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 获取订单数据失败: %v\n", err)
+		return 1
+	}
+
+	estimateTime, err := parseOrderResponse(rawData)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("订单号: %s\n", config.OrderID)
+	fmt.Printf("官方预计时间: %s\n", estimateTime)
+	if database, _, err := openCLIDatabase(config.OrderID); err == nil {
+		if record, err := database.GetLatestRecord(config.OrderID); err == nil && record != nil && record.EstimateTime != estimateTime {
+			fmt.Printf("上次记录: %s (%s，已变化)\n", record.EstimateTime, record.CheckTime.Format(utils.DateTimeShort))
+		}
+		database.Close()
+	}
+	fmt.Println()
+	fmt.Println(delivery.NewInfo(config.LockOrderTime, config.EstimateWeeksMin, config.EstimateWeeksMax).GetDetailedDeliveryInfo())
+	fmt.Printf("Cookie 状态: %s\n", manager.GetStatus())
+	return 0
+}
+
+// runOnce 执行一次完整检查后退出，适用于外部调度器
+func runOnce(args []string) int {
+	fs := flag.NewFlagSet("once", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: lixiang-monitor once")
+		fmt.Fprintln(fs.Output(), "退出码: 0 未变化, 10 预计时间变化, 20 Cookie 失效, 30 接口错误, 1 其他错误")
+	}
+	fs.Parse(args)
+
+	if err := checkStartupPaths(); err != nil {
+		log.Printf("❌ %v", err)
+		return exitError
+	}
+
+	if err := db.CheckSchema(cfg.GetPaths().DBPath); err != nil {
+		log.Printf("❌ %v", err)
+		return exitError
+	}
+
+	monitor := NewMonitor()
+	defer monitor.closeDatabase()
+
+	result, err := monitor.checkOnce()
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return exitError
+	}
+
+	switch result {
+	case checkChanged:
+		return exitChanged
+	case checkCookieExpired:
+		return exitCookieExpired
+	case checkAPIError:
+		return exitAPIError
+	default:
+		return exitUnchanged
+	}
+}
+
+// runHistory 打印数据库中的检查记录
+func runHistory(args []string) int {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	limit := fs.Int("n", 20, "显示的记录条数")
+	changesOnly := fs.Bool("changes", false, "只显示预计时间发生变化的记录")
+	orderID := fs.String("order", "", "订单号，默认使用配置文件中的 order_id")
+	fs.Parse(args)
+
+	database, id, err := openCLIDatabase(*orderID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	defer database.Close()
+
+	total, err := database.GetRecordsCount(id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	changes, err := database.GetTimeChangedRecords(id, -1)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("📊 订单 %s: 共 %d 条检查记录，预计时间变更 %d 次\n\n", id, total, len(changes))
+
+	records := changes
+	if *changesOnly {
+		if *limit > 0 && len(records) > *limit {
+			records = records[:*limit]
+		}
+	} else {
+		records, err = database.GetRecordsByOrderID(id, *limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+	}
+
+	if len(records) == 0 {
+		fmt.Println("暂无记录")
+		return 0
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "检查时间\t预计交付时间\t之前的预计时间\t临近交付\t已通知")
+	for _, record := range records {
+		previous := "-"
+		if record.TimeChanged {
+			previous = record.PreviousEstimate
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			record.CheckTime.Format(utils.DateTimeShort),
+			record.EstimateTime,
+			previous,
+			yesNo(record.IsApproaching),
+			yesNo(record.NotificationSent))
+	}
+	tw.Flush()
+	return 0
+}
+
+// runNotifyTest 通过每个已配置的通知器发送测试消息
+func runNotifyTest(args []string) int {
+	fs := flag.NewFlagSet("notify-test", flag.ExitOnError)
+	fs.Parse(args)
+
+	config, err := loadCLIConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	if len(config.Notifiers) == 0 {
+		fmt.Fprintln(os.Stderr, "❌ 未配置任何通知器，请在 config.yaml 中配置微信群机器人、ServerChan 或 Bark")
+		return 1
+	}
+
+	title := "🧪 理想汽车监控测试消息"
+	content := fmt.Sprintf("这是一条测试消息，用于验证通知功能是否正常工作。\n\n订单号: %s\n发送时间: %s",
+		config.OrderID, time.Now().Format(utils.DateTimeFormat))
+
+	failed := 0
+	for _, n := range config.Notifiers {
+		// 直接调用通知器，不经过去重和限流
+		if err := n.Send(title, content); err != nil {
+			fmt.Printf("❌ %s: %v\n", notifier.Name(n), err)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ %s: 发送成功\n", notifier.Name(n))
+	}
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runCookie Cookie 管理子命令
+func runCookie(args []string) int {
+	usage := "用法: lixiang-monitor cookie <status|import> [参数]"
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	switch args[0] {
+	case "status":
+		return runCookieStatus(args[1:])
+	case "import":
+		return runImportCookies(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+}
+
+// runCookieStatus 打印 Cookie 状态
+func runCookieStatus(args []string) int {
+	fs := flag.NewFlagSet("cookie status", flag.ExitOnError)
+	fs.Parse(args)
+
+	config, err := loadCLIConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 加载配置失败: %v\n", err)
+		return 1
+	}
+	manager := newCLICookieManager(config)
+
+	fmt.Println(describeCookie(manager))
+	if expireTime, source := manager.ExpiryTime(); !expireTime.IsZero() {
+		fmt.Printf("过期时间: %s (依据 %s)\n", expireTime.Format(utils.DateTimeFormat), source)
+	}
+
+	cookies := cookie.ParseHeader(manager.Snapshot().Cookies)
+	fmt.Printf("Cookie 数量: %d\n", len(cookies))
+	for _, name := range cookie.AuthCookieNames {
+		if _, ok := cookies[name]; ok {
+			fmt.Printf("认证 Cookie: %s ✅\n", name)
+		} else {
+			fmt.Printf("认证 Cookie: %s ❌ 缺失\n", name)
+		}
+	}
+	return 0
+}
+
+// runConfig 配置管理子命令
+func runConfig(args []string) int {
+	if len(args) == 0 || (args[0] != "validate" && args[0] != "show") {
+		fmt.Fprintln(os.Stderr, "用法: lixiang-monitor config <validate|show>")
+		return 2
+	}
+	if args[0] == "show" {
+		return runConfigShow()
+	}
+
+	config, err := loadCLIConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	problems, warnings := validateConfig(config)
+	if viper.ConfigFileUsed() != "" {
+		fmt.Printf("配置文件: %s\n", viper.ConfigFileUsed())
+	}
+	for _, warning := range warnings {
+		fmt.Printf("⚠️  %s\n", warning)
+	}
+	for _, problem := range problems {
+		fmt.Printf("❌ %s\n", problem)
+	}
+
+	if len(problems) > 0 {
+		fmt.Printf("配置校验失败: %d 个错误，%d 个警告\n", len(problems), len(warnings))
+		return 1
+	}
+	fmt.Printf("✅ 配置校验通过（%d 个警告）\n", len(warnings))
+	return 0
+}
+
+// runConfigShow 打印当前生效的配置（含环境变量和密钥文件覆盖），敏感项已脱敏
+func runConfigShow() int {
+	if _, err := loadCLIConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	settings := cfg.Settings()
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if viper.ConfigFileUsed() != "" {
+		fmt.Printf("# 配置文件: %s\n", viper.ConfigFileUsed())
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, key := range keys {
+		fmt.Fprintf(tw, "%s\t%s\n", key, settings[key])
+	}
+	tw.Flush()
+	return 0
+}
+
+// validateConfig 校验配置，返回错误和警告
+func validateConfig(config *cfg.Config) (problems, warnings []string) {
+	if viper.ConfigFileUsed() == "" {
+		problems = append(problems, "未找到配置文件 config.yaml")
+	}
+
+	if config.OrderID == "" {
+		problems = append(problems, "order_id 不能为空")
+	}
+	if strings.TrimSpace(config.LixiangCookies) == "" {
+		problems = append(problems, "lixiang_cookies 不能为空")
+	}
+
+	if _, err := utils.ParseLockOrderTime(viper.GetString("lock_order_time")); err != nil {
+		problems = append(problems, fmt.Sprintf("lock_order_time 格式错误: %v", err))
+	}
+	if config.EstimateWeeksMin <= 0 || config.EstimateWeeksMax < config.EstimateWeeksMin {
+		problems = append(problems, fmt.Sprintf("estimate_weeks_min/max 无效: %d-%d", config.EstimateWeeksMin, config.EstimateWeeksMax))
+	}
+
+	// 定时任务表达式
+	parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+	schedules := []struct{ key, spec string }{
+		{"check_interval", config.CheckInterval},
+		{"digest_schedule", config.DigestSchedule},
+		{"cookie_keepalive_schedule", config.KeepaliveSchedule},
+	}
+	for _, schedule := range schedules {
+		if schedule.spec == "" {
+			continue
+		}
+		if _, err := parser.Parse(schedule.spec); err != nil {
+			problems = append(problems, fmt.Sprintf("%s 不是有效的 cron 表达式: %v", schedule.key, err))
+		}
+	}
+	if config.CheckInterval == "" {
+		problems = append(problems, "check_interval 不能为空")
+	}
+
+	if len(config.Notifiers) == 0 {
+		warnings = append(warnings, "未配置任何通知器，将不会发送通知")
+	}
+
+	if rotation := viper.GetString("header_rotation"); !cookie.ValidRotation(rotation) {
+		problems = append(problems, fmt.Sprintf("header_rotation 无效: %s（可选 none、round_robin、random）", rotation))
+	}
+
+	if config.WeComCallbackToken != "" {
+		if len(config.WeComCallbackAESKey) != 43 {
+			problems = append(problems, "wecom_callback_aes_key 必须为 43 个字符")
+		}
+		if config.WeComCorpID == "" {
+			problems = append(problems, "启用企业微信命令回调时 wecom_corp_id 不能为空")
+		}
+		if (config.WeComCorpSecret == "") != (config.WeComAgentID == 0) {
+			problems = append(problems, "wecom_corp_secret 和 wecom_agent_id 必须同时设置")
+		} else if config.WeComCorpSecret == "" {
+			warnings = append(warnings, "未配置 wecom_corp_secret 和 wecom_agent_id，/check 等较慢的命令无法在 5 秒内回复结果")
+		}
+	}
+	if (config.WeComCallbackToken != "" || config.WebCookieToken != "") && !config.WebEnabled {
+		warnings = append(warnings, "web_cookie_token 或企业微信命令回调需要 web_enabled: true")
+	}
+	if (config.WebTLSCertFile == "") != (config.WebTLSKeyFile == "") {
+		problems = append(problems, "web_tls_cert_file 和 web_tls_key_file 必须同时设置")
+	} else if config.WebTLSCertFile != "" {
+		if _, err := tls.LoadX509KeyPair(config.WebTLSCertFile, config.WebTLSKeyFile); err != nil {
+			problems = append(problems, fmt.Sprintf("无法加载 HTTPS 证书: %v", err))
+		}
+	}
+	if config.WebTLSRedirectPort > 0 && config.WebTLSCertFile == "" {
+		warnings = append(warnings, "web_tls_redirect_port 仅在配置证书后生效")
+	}
+	if (config.WebAuthUsername == "") != (config.WebAuthPassword == "") {
+		problems = append(problems, "web_auth_username 和 web_auth_password 必须同时设置")
+	}
+	if config.CalendarToken != "" && config.CalendarToken == config.WebAuthToken {
+		problems = append(problems, "calendar_token 不能与 web_auth_token 相同，订阅地址中的令牌应只能读取日历")
+	}
+	if config.TelegramBotToken != "" && len(config.TelegramAllowedChatIDs) == 0 {
+		warnings = append(warnings, "未配置 telegram_allowed_chat_ids，所有 Telegram 命令都会被拒绝")
+	}
+	if config.WeComCallbackToken != "" && len(config.WeComAllowedUserIDs) == 0 {
+		warnings = append(warnings, "未配置 wecom_allowed_user_ids，所有企业微信命令都会被拒绝")
+	}
+
+	return problems, warnings
+}
+
+// runExport 导出检查记录
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	formatName := fs.String("format", "", "导出格式: json、ndjson、csv 或 xlsx，默认根据输出文件扩展名判断，否则为 json")
+	output := fs.String("o", "", "输出文件，默认输出到标准输出")
+	orderID := fs.String("order", "", "订单号，默认使用配置文件中的 order_id")
+	since := fs.String("since", "", "只导出该时间之后的记录，例如 2025-10-01 或 2025-10-01 08:00:00")
+	until := fs.String("until", "", "只导出该时间之前的记录，只给出日期时包含当天")
+	tz := fs.String("tz", "", "导出时间使用的时区，例如 Asia/Shanghai，默认本地时区")
+	fs.Parse(args)
+
+	format, err := exportFormat(*formatName, *output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 2
+	}
+
+	if *output == "" && format == export.FormatXLSX {
+		fmt.Fprintln(os.Stderr, "❌ xlsx 格式需要使用 -o 指定输出文件")
+		return 2
+	}
+
+	loc := time.Local
+	if *tz != "" {
+		if loc, err = time.LoadLocation(*tz); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ 未知时区: %s\n", *tz)
+			return 2
+		}
+	}
+
+	filter := db.RecordFilter{}
+	if filter.Since, err = utils.ParseRangeTime(*since, loc, false); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ -since 无效: %v\n", err)
+		return 2
+	}
+	if filter.Until, err = utils.ParseRangeTime(*until, loc, true); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ -until 无效: %v\n", err)
+		return 2
+	}
+
+	database, id, err := openCLIDatabase(*orderID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	defer database.Close()
+
+	filter.OrderID = id
+	records, err := database.FindRecords(filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ 创建输出文件失败: %v\n", err)
+			return 1
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if err := export.Write(w, format, records, loc); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 导出失败: %v\n", err)
+		return 1
+	}
+
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "✅ 已导出 %d 条记录到 %s\n", len(records), *output)
+	}
+	return 0
+}
+
+// exportFormat 确定导出格式，未指定时根据输出文件扩展名判断
+func exportFormat(name, output string) (export.Format, error) {
+	if name != "" {
+		return export.ParseFormat(name)
+	}
+	if ext := strings.TrimPrefix(filepath.Ext(output), "."); ext != "" {
+		if format, err := export.ParseFormat(ext); err == nil {
+			return format, nil
+		}
+	}
+	return export.FormatJSON, nil
+}
+
+// openCLIDatabase 以只读方式打开数据库，orderID 为空时使用配置文件中的订单号
+func openCLIDatabase(orderID string) (*db.Database, string, error) {
+	if orderID == "" {
+		config, err := loadCLIConfig()
+		if err != nil {
+			return nil, "", err
+		}
+		orderID = config.OrderID
+	}
+
+	dbPath := cfg.GetPaths().DBPath
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, "", fmt.Errorf("数据库文件不存在: %s", dbPath)
+	}
+
+	// 只读打开：查看记录不应创建文件或升级数据库结构，也不会与正在运行的服务争抢写锁
+	database, err := db.OpenReadOnly(dbPath)
+	if err != nil {
+		return nil, "", err
+	}
+	return database, orderID, nil
+}
+
+// yesNo 将布尔值显示为 是/否
+func yesNo(b bool) string {
+	if b {
+		return "是"
+	}
+	return "否"
+}
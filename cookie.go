@@ -0,0 +1,306 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"lixiang-monitor/cfg"
+	"lixiang-monitor/cookie"
+	"lixiang-monitor/db"
+	"lixiang-monitor/utils"
+)
+
+// TestAndUpdateCookie 实现 web.CookieUpdater 接口
+// input 可以是 Cookie 请求头字符串，也可以是 cookies.txt、JSON 或 HAR 格式的浏览器导出内容。
+// 先用新 Cookie 实时请求一次订单数据，验证通过后写回配置文件并重置 Cookie 管理器状态
+func (m *Monitor) TestAndUpdateCookie(input string) (string, error) {
+	if m.stopping.Load() {
+		return "", fmt.Errorf("监控服务正在停止，请稍后重试")
+	}
+
+	cookies, updatedAt, expiresAt, err := parseCookieInput(input)
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.RLock()
+	orderID := m.OrderID
+	headers := m.LixiangHeaders
+	profiles := m.HeaderProfiles
+	rotation := m.HeaderRotation
+	m.mu.RUnlock()
+
+	// 使用独立的管理器验证，避免影响当前的失败计数
+	estimateTime, err := verifyCookie(orderID, cookies, headers, profiles, rotation)
+	if err != nil {
+		return "", err
+	}
+
+	if err := m.saveCookie(cookies, updatedAt, expiresAt); err != nil {
+		return "", err
+	}
+
+	log.Printf("✅ Cookie 已更新并验证通过，当前预计交付时间: %s", estimateTime)
+	m.publishCookieStatus()
+	return estimateTime, nil
+}
+
+// describeCookie 返回 Cookie 状态、更新时间、连续失败次数和最近一次保活结果
+func describeCookie(cm *cookie.Manager) string {
+	state := cm.Snapshot()
+	status := fmt.Sprintf("Cookie 状态: %s\n更新时间: %s\n连续失败: %d 次",
+		cm.GetStatus(),
+		state.UpdatedAt.Format(utils.DateTimeFormat),
+		state.ConsecutiveFailure)
+
+	if !state.LastKeepaliveTime.IsZero() {
+		result := "会话有效"
+		if !state.LastKeepaliveOK {
+			result = "会话无效"
+		}
+		status += fmt.Sprintf("\n最近保活: %s (%s)", state.LastKeepaliveTime.Format(utils.DateTimeFormat), result)
+	}
+	return status
+}
+
+// newCLICookieManager 按配置创建独立的 Cookie 管理器，供只获取订单数据、不启动监控服务的命令使用
+// 与监控服务一样优先使用状态文件中服务端刷新后的 Cookie，请求中再次刷新的 Cookie 也会保存
+func newCLICookieManager(config *cfg.Config) *cookie.Manager {
+	headers := cookie.BaseHeaders()
+	manager := cookie.NewManager(config.LixiangCookies, headers, config.CookieValidDays, config.CookieUpdatedAt)
+	manager.SetTimes(config.CookieUpdatedAt, config.CookieExpiresAt)
+	manager.SetProfiles(config.HeaderProfiles, config.HeaderRotation)
+
+	if config.CookieStateFile != "" && !config.CookiePersistToConfig {
+		restoreCookieState(manager, config.CookieStateFile, config.LixiangCookies, headers)
+	}
+
+	origin := cookie.OriginHash(config.LixiangCookies)
+	manager.OnCookieRefreshed = func(cookies string, updatedAt, expiresAt time.Time) {
+		saveRefreshedCookie(config.CookiePersistToConfig, config.CookieStateFile, origin, cookies, updatedAt, expiresAt)
+	}
+	return manager
+}
+
+// verifyCookie 使用新 Cookie 实时请求一次订单数据，返回当前预计交付时间
+func verifyCookie(orderID, cookies string, headers map[string]string, profiles []cookie.HeaderProfile, rotation string) (string, error) {
+	tester := cookie.NewManager(cookies, headers, 0, time.Now())
+	tester.SetProfiles(profiles, rotation)
+	rawData, err := tester.FetchOrderData(orderID)
+	if err != nil {
+		return "", err
+	}
+	return parseOrderResponse(rawData)
+}
+
+// writeCookieConfig 将 Cookie 及其更新、过期时间写回配置文件
+func writeCookieConfig(cookies string, updatedAt, expiresAt time.Time) error {
+	expiresAtStr := ""
+	if !expiresAt.IsZero() {
+		expiresAtStr = expiresAt.Local().Format(utils.DateTimeFormat)
+	}
+
+	return cfg.SetValues(map[string]string{
+		"lixiang_cookies":   cookies,
+		"cookie_updated_at": updatedAt.Local().Format(utils.DateTimeFormat),
+		"cookie_expires_at": expiresAtStr,
+	})
+}
+
+// saveCookie 将 Cookie 写回配置文件并重置 Cookie 管理器状态
+func (m *Monitor) saveCookie(cookies string, updatedAt, expiresAt time.Time) error {
+	if err := writeCookieConfig(cookies, updatedAt, expiresAt); err != nil {
+		return fmt.Errorf("保存 Cookie 失败: %v", err)
+	}
+
+	m.mu.Lock()
+	m.LixiangCookies = cookies
+	m.CookieUpdatedAt = updatedAt
+	m.CookieExpiresAt = expiresAt
+	headers := m.LixiangHeaders
+	m.mu.Unlock()
+
+	// 与订单检查和会话保活共用 cookieManager，等待进行中的请求完成后再替换
+	m.checkMu.Lock()
+	defer m.checkMu.Unlock()
+	m.cookieManager.UpdateCookie(cookies, headers)
+	m.cookieManager.SetTimes(updatedAt, expiresAt)
+	return nil
+}
+
+// keepaliveCookie 请求一次需要登录的接口，保持会话活跃并尽早发现 Cookie 失效
+func (m *Monitor) keepaliveCookie() {
+	// 与订单检查共用 cookieManager，需串行执行
+	m.checkMu.Lock()
+	defer m.checkMu.Unlock()
+
+	m.mu.RLock()
+	orderID := m.OrderID
+	url := m.KeepaliveURL
+	m.mu.RUnlock()
+
+	if url == "" {
+		url = fmt.Sprintf(cookie.OrderDetailURL, orderID)
+	}
+
+	if err := m.cookieManager.Keepalive(url); err != nil {
+		log.Printf("⚠️  会话保活失败: %v", err)
+		m.saveCheckFailure(orderID, db.FailureKeepalive, err)
+		m.publishCookieStatus()
+		return
+	}
+	log.Println("会话保活成功，Cookie 仍然有效")
+	m.publishCookieStatus()
+}
+
+// persistRefreshedCookie 保存服务端通过 Set-Cookie 刷新后的 Cookie
+// 默认写入独立的状态文件，启用 cookie_persist_to_config 时写回 config.yaml
+func (m *Monitor) persistRefreshedCookie(cookies string, updatedAt, expiresAt time.Time) {
+	m.mu.RLock()
+	persistToConfig := m.CookiePersistToConfig
+	stateFile := m.CookieStateFile
+	origin := cookie.OriginHash(m.LixiangCookies)
+	m.mu.RUnlock()
+
+	saveRefreshedCookie(persistToConfig, stateFile, origin, cookies, updatedAt, expiresAt)
+}
+
+// saveRefreshedCookie 将刷新后的 Cookie 写回配置文件或状态文件，origin 为派生自的配置 Cookie 的摘要
+func saveRefreshedCookie(persistToConfig bool, stateFile, origin, cookies string, updatedAt, expiresAt time.Time) {
+	if persistToConfig {
+		// 写入触发的热加载由 cfg.SelfWritten 识别，不会发送配置更新通知
+		if err := writeCookieConfig(cookies, updatedAt, expiresAt); err != nil {
+			log.Printf("⚠️  刷新后的 Cookie 写回配置文件失败: %v", err)
+		}
+		return
+	}
+
+	if stateFile == "" {
+		return
+	}
+
+	state := &cookie.State{
+		Cookies:   cookies,
+		Origin:    origin,
+		UpdatedAt: updatedAt,
+		ExpiresAt: expiresAt,
+	}
+	if err := cookie.SaveState(stateFile, state); err != nil {
+		log.Printf("⚠️  保存 Cookie 状态文件失败: %v", err)
+		return
+	}
+	log.Printf("刷新后的 Cookie 已保存到 %s", stateFile)
+}
+
+// applyCookieState 如果状态文件中的 Cookie 派生自当前配置，则使用状态文件中的 Cookie
+// 调用方需持有 m.mu 或处于初始化阶段
+func (m *Monitor) applyCookieState() {
+	if m.cookieManager == nil || m.CookieStateFile == "" || m.CookiePersistToConfig {
+		return
+	}
+	restoreCookieState(m.cookieManager, m.CookieStateFile, m.LixiangCookies, m.LixiangHeaders)
+}
+
+// restoreCookieState 状态文件中的 Cookie 派生自 configCookies 时，用它更新 Cookie 管理器
+// 状态文件只在服务端刷新配置中的 Cookie 后写入，来源摘要一致即说明它更新；
+// 不比较更新时间，因为未配置 cookie_updated_at 时配置中的更新时间是加载时的当前时间
+func restoreCookieState(manager *cookie.Manager, stateFile, configCookies string, headers map[string]string) {
+	state, err := cookie.LoadState(stateFile)
+	if err != nil {
+		log.Printf("⚠️  %v", err)
+		return
+	}
+	if state == nil || state.Origin != cookie.OriginHash(configCookies) {
+		return
+	}
+
+	expiresAt := state.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = manager.Snapshot().ExpiresAt
+	}
+	manager.UpdateCookie(state.Cookies, headers)
+	manager.SetTimes(state.UpdatedAt, expiresAt)
+	log.Printf("已从 %s 恢复服务端刷新后的 Cookie (更新时间: %s)", stateFile, state.UpdatedAt.Format(utils.DateTimeFormat))
+}
+
+// parseCookieInput 解析 Cookie 输入，返回请求头字符串、更新时间和过期时间
+func parseCookieInput(input string) (string, time.Time, time.Time, error) {
+	export, err := cookie.ParseExport([]byte(input))
+	if errors.Is(err, cookie.ErrNotExport) {
+		return input, time.Now(), time.Time{}, nil
+	}
+	if err != nil {
+		return "", time.Time{}, time.Time{}, err
+	}
+
+	updatedAt := export.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = time.Now()
+	}
+
+	log.Printf("已从 %s 格式的导出内容中解析 %d 个 Cookie", export.Format, len(export.Cookies))
+	return export.Header(), updatedAt, export.ExpiresAt(), nil
+}
+
+// runImportCookies 从浏览器导出文件导入 Cookie
+func runImportCookies(args []string) int {
+	fs := flag.NewFlagSet("import-cookies", flag.ExitOnError)
+	skipTest := fs.Bool("skip-test", false, "跳过实时请求验证，直接保存")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: lixiang-monitor import-cookies [--skip-test] <cookies.txt|cookies.json|export.har>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		log.Printf("读取文件失败: %v", err)
+		return 1
+	}
+
+	cookies, updatedAt, expiresAt, err := parseCookieInput(string(data))
+	if err != nil {
+		log.Printf("❌ 导入 Cookie 失败: %v", err)
+		return 1
+	}
+
+	// 只需要订单号和请求头配置，不创建完整的 Monitor（不打开数据库、不启动配置监听）
+	config, err := loadCLIConfig()
+	if err != nil {
+		log.Printf("❌ 加载配置失败: %v", err)
+		return 1
+	}
+
+	estimateTime := ""
+	if !*skipTest {
+		estimateTime, err = verifyCookie(config.OrderID, cookies, cookie.BaseHeaders(), config.HeaderProfiles, config.HeaderRotation)
+		if err != nil {
+			log.Printf("❌ 导入 Cookie 失败: %v", err)
+			return 1
+		}
+	}
+
+	if err := writeCookieConfig(cookies, updatedAt, expiresAt); err != nil {
+		log.Printf("❌ 保存 Cookie 失败: %v", err)
+		return 1
+	}
+
+	if *skipTest {
+		log.Println("✅ Cookie 已导入（未验证）")
+	} else {
+		log.Printf("✅ Cookie 已导入，当前预计交付时间: %s", estimateTime)
+	}
+	if !expiresAt.IsZero() {
+		log.Printf("Cookie 过期时间: %s", expiresAt.Local().Format(utils.DateTimeFormat))
+	}
+	return 0
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 
 	"lixiang-monitor/utils"
@@ -22,53 +23,139 @@ func (e *CookieExpiredError) Error() string {
 }
 
 // Manager Cookie 管理器
+// Cookies 到 LastKeepaliveOK 的状态字段由 mu 保护，包外并发读取请使用 Snapshot，修改请使用对应的方法
 type Manager struct {
+	mu                        sync.Mutex
 	Cookies                   string
 	Headers                   map[string]string
 	ValidDays                 int
 	UpdatedAt                 time.Time
+	ExpiresAt                 time.Time // 从 Cookie 属性中得到的过期时间，未知时为零值
 	ExpirationWarned          bool
 	ConsecutiveFailure        int
 	ExpiredNotified           bool
 	LastCheckTime             time.Time
+	LastKeepaliveTime         time.Time // 最近一次保活请求的时间
+	LastKeepaliveOK           bool      // 最近一次保活请求时会话是否有效
 	OnCookieExpired           func(statusCode int, message string)
 	OnCookieExpirationWarning func(timeDesc, expireTime, updatedAt string, ageInDays float64)
+	OnCookieRefreshed         func(cookies string, updatedAt, expiresAt time.Time) // 服务端通过 Set-Cookie 刷新会话后回调
+
+	jar    *Jar
+	client *http.Client
+
+	profileMu   sync.Mutex
+	profiles    []HeaderProfile // 浏览器指纹请求头配置
+	rotation    string          // 配置轮换方式
+	nextProfile int
 }
 
 // NewManager 创建 Cookie 管理器
 func NewManager(cookies string, headers map[string]string, validDays int, updatedAt time.Time) *Manager {
+	jar := NewJar(cookies)
 	return &Manager{
 		Cookies:   cookies,
 		Headers:   headers,
 		ValidDays: validDays,
 		UpdatedAt: updatedAt,
+		jar:       jar,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+			Jar:     jar,
+		},
+		profiles: []HeaderProfile{DefaultProfile()},
+	}
+}
+
+// Snapshot Cookie 管理器状态的只读副本
+type Snapshot struct {
+	Cookies            string
+	ValidDays          int
+	UpdatedAt          time.Time
+	ExpiresAt          time.Time
+	ExpirationWarned   bool
+	ConsecutiveFailure int
+	ExpiredNotified    bool
+	LastCheckTime      time.Time
+	LastKeepaliveTime  time.Time
+	LastKeepaliveOK    bool
+}
+
+// Snapshot 加锁读取当前状态，供健康检查、状态推送等与请求并发的调用方使用
+func (cm *Manager) Snapshot() Snapshot {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	return Snapshot{
+		Cookies:            cm.Cookies,
+		ValidDays:          cm.ValidDays,
+		UpdatedAt:          cm.UpdatedAt,
+		ExpiresAt:          cm.ExpiresAt,
+		ExpirationWarned:   cm.ExpirationWarned,
+		ConsecutiveFailure: cm.ConsecutiveFailure,
+		ExpiredNotified:    cm.ExpiredNotified,
+		LastCheckTime:      cm.LastCheckTime,
+		LastKeepaliveTime:  cm.LastKeepaliveTime,
+		LastKeepaliveOK:    cm.LastKeepaliveOK,
 	}
 }
 
+// SetTimes 设置 Cookie 的更新时间和过期时间（过期时间未知时为零值）
+func (cm *Manager) SetTimes(updatedAt, expiresAt time.Time) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.UpdatedAt = updatedAt
+	cm.ExpiresAt = expiresAt
+}
+
+// SetValidDays 设置 Cookie 有效天数
+func (cm *Manager) SetValidDays(validDays int) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.ValidDays = validDays
+}
+
+// OrderDetailURL 订单详情接口地址
+const OrderDetailURL = "https://api-web.lixiang.com/vehicle-api/v1-0/orders/pointer/vehicleOrderDetail_PC/%s"
+
 // FetchOrderData 获取订单数据
 func (cm *Manager) FetchOrderData(orderID string) (interface{}, error) {
-	url := fmt.Sprintf("https://api-web.lixiang.com/vehicle-api/v1-0/orders/pointer/vehicleOrderDetail_PC/%s", orderID)
-
-	req, err := http.NewRequest("GET", url, nil)
+	orderResp, err := cm.get(fmt.Sprintf(OrderDetailURL, orderID))
 	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %v", err)
+		return nil, err
 	}
+	return orderResp, nil
+}
 
-	// 设置请求头
-	for key, value := range cm.Headers {
-		req.Header.Set(key, value)
+// Keepalive 请求一个需要登录的接口以保持会话活跃，并记录会话是否仍然有效
+// 会话失效时立即触发 OnCookieExpired，不等待连续失败 3 次
+func (cm *Manager) Keepalive(url string) error {
+	_, err := cm.get(url)
+	cm.mu.Lock()
+	cm.LastKeepaliveTime = time.Now()
+	cm.LastKeepaliveOK = err == nil
+	cm.mu.Unlock()
+
+	if expiredErr, ok := err.(*CookieExpiredError); ok {
+		cm.notifyExpired(expiredErr.StatusCode, expiredErr.Message)
 	}
+	return err
+}
 
-	// 设置 Cookie
-	if cm.Cookies != "" {
-		req.Header.Set("Cookie", cm.Cookies)
+// get 携带 Cookie 请求理想汽车接口，检测 Cookie 失效并接收服务端刷新的 Cookie
+func (cm *Manager) get(url string) (map[string]interface{}, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %v", err)
 	}
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+	// 设置请求头（每次请求生成新的追踪 ID，并按配置轮换浏览器指纹）
+	for key, value := range cm.requestHeaders() {
+		req.Header.Set(key, value)
 	}
 
-	resp, err := client.Do(req)
+	// Cookie 由 cm.jar 负责携带，并接收响应中的 Set-Cookie
+	resp, err := cm.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("请求失败: %v", err)
 	}
@@ -81,6 +168,7 @@ func (cm *Manager) FetchOrderData(orderID string) (interface{}, error) {
 
 	// 检测 Cookie 失效的常见状态码
 	if resp.StatusCode == 401 || resp.StatusCode == 403 {
+		cm.discardRefresh()
 		cm.handleExpired(resp.StatusCode, string(body))
 		return nil, &CookieExpiredError{
 			StatusCode: resp.StatusCode,
@@ -89,11 +177,13 @@ func (cm *Manager) FetchOrderData(orderID string) (interface{}, error) {
 	}
 
 	if resp.StatusCode != 200 {
+		cm.discardRefresh()
 		return nil, fmt.Errorf("API 返回错误状态码: %d, 响应: %s", resp.StatusCode, string(body))
 	}
 
 	var orderResp map[string]interface{}
 	if err := json.Unmarshal(body, &orderResp); err != nil {
+		cm.discardRefresh()
 		return nil, fmt.Errorf("解析 JSON 失败: %v", err)
 	}
 
@@ -103,6 +193,7 @@ func (cm *Manager) FetchOrderData(orderID string) (interface{}, error) {
 		if msg, ok := orderResp["message"].(string); ok {
 			message = msg
 		}
+		cm.discardRefresh()
 
 		// 常见的认证失败错误码
 		if int(code) == 401 || int(code) == 403 ||
@@ -117,106 +208,208 @@ func (cm *Manager) FetchOrderData(orderID string) (interface{}, error) {
 	}
 
 	// 请求成功，重置失败计数器
+	cm.mu.Lock()
 	cm.ConsecutiveFailure = 0
 	cm.ExpiredNotified = false
 	cm.LastCheckTime = time.Now()
+	cm.mu.Unlock()
+
+	cm.applyRefresh()
 
 	return orderResp, nil
 }
 
+// applyRefresh 请求成功后应用服务端下发的新 Cookie
+func (cm *Manager) applyRefresh() {
+	if !cm.jar.TakeChanged() {
+		return
+	}
+
+	cm.mu.Lock()
+	cm.Cookies = cm.jar.Header()
+	cm.UpdatedAt = time.Now()
+	if expiresAt := cm.jar.AuthExpiry(); !expiresAt.IsZero() {
+		cm.ExpiresAt = expiresAt
+	}
+	cm.ExpirationWarned = false
+	cookies, updatedAt, expiresAt := cm.Cookies, cm.UpdatedAt, cm.ExpiresAt
+	cm.mu.Unlock()
+	log.Println("🔄 服务端已刷新 Cookie，会话有效期已延长")
+
+	// 回调在锁外执行，回调中可以读取 Snapshot
+	if cm.OnCookieRefreshed != nil {
+		cm.OnCookieRefreshed(cookies, updatedAt, expiresAt)
+	}
+}
+
+// discardRefresh 请求失败时丢弃服务端下发的 Cookie 变更，避免保存失效的会话
+func (cm *Manager) discardRefresh() {
+	if cm.jar.TakeChanged() {
+		cm.mu.Lock()
+		cm.jar.Reset(cm.Cookies)
+		cm.mu.Unlock()
+	}
+}
+
 // CheckExpiration 检查 Cookie 是否即将过期
 func (cm *Manager) CheckExpiration() {
-	if cm.ValidDays == 0 {
-		return // 未配置有效期，跳过检查
+	cm.mu.Lock()
+	expireTime, source := cm.expiryTime()
+	if expireTime.IsZero() {
+		cm.mu.Unlock()
+		return // 无法确定过期时间，跳过检查
 	}
 
 	// 计算 Cookie 年龄和剩余时间
-	cookieAge := time.Since(cm.UpdatedAt)
-	expireTime := cm.UpdatedAt.Add(time.Duration(cm.ValidDays) * 24 * time.Hour)
+	updatedAt := cm.UpdatedAt
+	cookieAge := time.Since(updatedAt)
 	remaining := time.Until(expireTime)
 
 	// 提前 2 天开始预警（48 小时）
 	warningThreshold := 48 * time.Hour
 
+	warn := false
 	if remaining > 0 && remaining < warningThreshold && !cm.ExpirationWarned {
-		// 计算剩余天数和小时数
-		remainingDays := int(remaining.Hours() / 24)
-		remainingHours := int(remaining.Hours()) % 24
-
-		var timeDesc string
-		if remainingDays > 0 {
-			timeDesc = fmt.Sprintf("%d 天 %d 小时", remainingDays, remainingHours)
-		} else {
-			timeDesc = fmt.Sprintf("%d 小时", remainingHours)
-		}
-
+		// 先标记已预警再在锁外发送，避免并发检查重复预警
 		if cm.OnCookieExpirationWarning != nil {
-			cm.OnCookieExpirationWarning(
-				timeDesc,
-				expireTime.Format(utils.DateTimeFormat),
-				cm.UpdatedAt.Format(utils.DateTimeFormat),
-				cookieAge.Hours()/24,
-			)
 			cm.ExpirationWarned = true
-			log.Printf("✅ Cookie 过期预警通知已发送（剩余: %s）", timeDesc)
+			warn = true
 		}
 	} else if remaining < 0 {
 		// Cookie 已过期
 		if !cm.ExpirationWarned {
-			log.Printf("⚠️  Cookie 已过期 %s", time.Since(expireTime))
+			log.Printf("⚠️  Cookie 已过期 %s (依据 %s)", time.Since(expireTime), source)
 		}
 	} else if remaining > warningThreshold && cm.ExpirationWarned {
 		// Cookie 已更新，重置预警状态
 		cm.ExpirationWarned = false
 	}
+	cm.mu.Unlock()
+
+	if !warn {
+		return
+	}
+
+	// 计算剩余天数和小时数
+	remainingDays := int(remaining.Hours() / 24)
+	remainingHours := int(remaining.Hours()) % 24
+
+	var timeDesc string
+	if remainingDays > 0 {
+		timeDesc = fmt.Sprintf("%d 天 %d 小时", remainingDays, remainingHours)
+	} else {
+		timeDesc = fmt.Sprintf("%d 小时", remainingHours)
+	}
+
+	cm.OnCookieExpirationWarning(
+		timeDesc,
+		fmt.Sprintf("%s (依据 %s)", expireTime.Format(utils.DateTimeFormat), source),
+		updatedAt.Format(utils.DateTimeFormat),
+		cookieAge.Hours()/24,
+	)
+	log.Printf("✅ Cookie 过期预警通知已发送（剩余: %s）", timeDesc)
 }
 
 // GetStatus 获取 Cookie 状态信息
 func (cm *Manager) GetStatus() string {
-	if cm.ValidDays == 0 {
+	expireTime, source := cm.ExpiryTime()
+	if expireTime.IsZero() {
 		return "未配置过期检测"
 	}
 
-	expireTime := cm.UpdatedAt.Add(time.Duration(cm.ValidDays) * 24 * time.Hour)
 	remaining := time.Until(expireTime)
 
 	if remaining < 0 {
-		return fmt.Sprintf("❌ 已过期 %s", time.Since(expireTime).Round(time.Hour))
+		return fmt.Sprintf("❌ 已过期 %s（依据 %s）", time.Since(expireTime).Round(time.Hour), source)
 	} else if remaining < 24*time.Hour {
-		return fmt.Sprintf("⚠️  即将过期（剩余 %d 小时）", int(remaining.Hours()))
+		return fmt.Sprintf("⚠️  即将过期（剩余 %d 小时，依据 %s）", int(remaining.Hours()), source)
 	} else if remaining < 48*time.Hour {
-		return fmt.Sprintf("⚠️  即将过期（剩余 %.1f 天）", remaining.Hours()/24)
+		return fmt.Sprintf("⚠️  即将过期（剩余 %.1f 天，依据 %s）", remaining.Hours()/24, source)
 	} else {
-		return fmt.Sprintf("🟢 正常（剩余 %.1f 天）", remaining.Hours()/24)
+		return fmt.Sprintf("🟢 正常（剩余 %.1f 天，依据 %s）", remaining.Hours()/24, source)
 	}
 }
 
 // handleExpired 处理 Cookie 失效的情况
 func (cm *Manager) handleExpired(statusCode int, message string) {
+	cm.mu.Lock()
 	cm.ConsecutiveFailure++
+	failures := cm.ConsecutiveFailure
+	cm.mu.Unlock()
 
 	log.Printf("⚠️  Cookie 验证失败 (状态码: %d, 连续失败: %d 次): %s",
-		statusCode, cm.ConsecutiveFailure, message)
+		statusCode, failures, message)
 
 	// 连续失败 3 次且未通知过，则发送告警
-	if cm.ConsecutiveFailure >= 3 && !cm.ExpiredNotified {
-		if cm.OnCookieExpired != nil {
-			cm.OnCookieExpired(statusCode, message)
-			cm.ExpiredNotified = true
-			log.Println("✅ Cookie 失效通知已发送")
-		}
+	if failures >= 3 {
+		cm.notifyExpired(statusCode, message)
+	}
+}
+
+// notifyExpired 发送 Cookie 失效通知（每次失效只通知一次）
+// 回调在锁外执行，回调中可以读取 Snapshot
+func (cm *Manager) notifyExpired(statusCode int, message string) {
+	cm.mu.Lock()
+	if cm.ExpiredNotified || cm.OnCookieExpired == nil {
+		cm.mu.Unlock()
+		return
 	}
+	cm.ExpiredNotified = true
+	cm.mu.Unlock()
+
+	cm.OnCookieExpired(statusCode, message)
+	log.Println("✅ Cookie 失效通知已发送")
 }
 
 // ResetFailureCount 重置失败计数器
 func (cm *Manager) ResetFailureCount() {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
 	cm.ConsecutiveFailure = 0
 	cm.ExpiredNotified = false
 }
 
+// FailureState Cookie 失效检测和过期预警状态，供单次检查模式在两次运行之间保存和恢复
+type FailureState struct {
+	Origin             string `json:"origin"` // 所属 Cookie 的摘要，Cookie 更换或刷新后状态失效
+	ConsecutiveFailure int    `json:"consecutive_failure"`
+	ExpiredNotified    bool   `json:"expired_notified,omitempty"`
+	ExpirationWarned   bool   `json:"expiration_warned,omitempty"`
+}
+
+// FailureState 导出当前的失败计数和通知状态
+func (cm *Manager) FailureState() FailureState {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	return FailureState{
+		Origin:             OriginHash(cm.Cookies),
+		ConsecutiveFailure: cm.ConsecutiveFailure,
+		ExpiredNotified:    cm.ExpiredNotified,
+		ExpirationWarned:   cm.ExpirationWarned,
+	}
+}
+
+// RestoreFailureState 恢复之前导出的状态，状态不属于当前 Cookie 时忽略并返回 false
+func (cm *Manager) RestoreFailureState(state FailureState) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if state.Origin != OriginHash(cm.Cookies) {
+		return false
+	}
+	cm.ConsecutiveFailure = state.ConsecutiveFailure
+	cm.ExpiredNotified = state.ExpiredNotified
+	cm.ExpirationWarned = state.ExpirationWarned
+	return true
+}
+
 // UpdateCookie 更新 Cookie
 func (cm *Manager) UpdateCookie(cookies string, headers map[string]string) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
 	cm.Cookies = cookies
+	cm.jar.Reset(cookies)
 	cm.Headers = headers
 	cm.UpdatedAt = time.Now()
 	cm.ExpirationWarned = false
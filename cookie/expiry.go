@@ -0,0 +1,121 @@
+package cookie
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 过期时间来源
+const (
+	ExpirySourceJWT       = "JWT"
+	ExpirySourceAttribute = "Cookie 属性"
+	ExpirySourceValidDays = "cookie_valid_days"
+)
+
+// ExpiryTime 返回 Cookie 的实际过期时间及其来源
+// 依次尝试：认证 Cookie 中的 JWT exp 字段、导入时保存的 Expires/Max-Age、UpdatedAt + ValidDays。
+// 均无法得出时返回零值
+func (cm *Manager) ExpiryTime() (time.Time, string) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	return cm.expiryTime()
+}
+
+// expiryTime 计算过期时间，调用方需持有 cm.mu
+func (cm *Manager) expiryTime() (time.Time, string) {
+	if exp := jwtExpiry(cm.Cookies); !exp.IsZero() {
+		return exp, ExpirySourceJWT
+	}
+	if !cm.ExpiresAt.IsZero() {
+		return cm.ExpiresAt, ExpirySourceAttribute
+	}
+	if cm.ValidDays > 0 {
+		return cm.UpdatedAt.Add(time.Duration(cm.ValidDays) * 24 * time.Hour), ExpirySourceValidDays
+	}
+	return time.Time{}, ""
+}
+
+// ParseHeader 解析 Cookie 请求头字符串
+func ParseHeader(header string) map[string]string {
+	values := make(map[string]string)
+	for _, part := range strings.Split(header, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || name == "" {
+			continue
+		}
+		values[name] = value
+	}
+	return values
+}
+
+// jwtExpiry 在 Cookie 中查找 JWT 形式的认证令牌并读取 exp 字段
+// 优先检查 AuthCookieNames 中的 Cookie，其次检查其他任意 JWT 形式的值
+func jwtExpiry(header string) time.Time {
+	values := ParseHeader(header)
+
+	for _, name := range AuthCookieNames {
+		if value, ok := values[name]; ok {
+			if exp := parseJWTExpiry(value); !exp.IsZero() {
+				return exp
+			}
+		}
+	}
+
+	var earliest time.Time
+	for _, value := range values {
+		if exp := parseJWTExpiry(value); !exp.IsZero() && (earliest.IsZero() || exp.Before(earliest)) {
+			earliest = exp
+		}
+	}
+	return earliest
+}
+
+// parseJWTExpiry 解析 JWT 载荷中的 exp 字段，不校验签名
+func parseJWTExpiry(value string) time.Time {
+	if unescaped, err := url.QueryUnescape(value); err == nil {
+		value = unescaped
+	}
+	value = strings.TrimPrefix(strings.Trim(value, `"`), "Bearer ")
+
+	parts := strings.Split(value, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTSegment(parts[0], &header); err != nil || header.Alg == "" {
+		return time.Time{}
+	}
+
+	var claims struct {
+		Exp json.Number `json:"exp"`
+	}
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return time.Time{}
+	}
+
+	exp, err := claims.Exp.Float64()
+	if err != nil || exp <= 0 {
+		return time.Time{}
+	}
+
+	// 兼容以毫秒为单位的 exp
+	if exp > 1e12 {
+		exp /= 1000
+	}
+	return time.Unix(int64(exp), 0)
+}
+
+// decodeJWTSegment 解码 base64url 编码的 JWT 片段
+func decodeJWTSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,90 @@
+package cookie
+
+import (
+	"encoding/base64"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// makeJWT 构造测试用 JWT，签名部分不校验
+func makeJWT(header, claims string) string {
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(header)) + "." + enc([]byte(claims)) + ".c2lnbmF0dXJl"
+}
+
+func TestParseJWTExpiry(t *testing.T) {
+	const exp = 1767225600 // 2026-01-01 00:00:00 UTC
+	token := makeJWT(`{"alg":"HS256"}`, `{"exp":1767225600}`)
+
+	tests := []struct {
+		name  string
+		value string
+		want  int64 // 0 表示无法解析
+	}{
+		{"plain", token, exp},
+		{"milliseconds", makeJWT(`{"alg":"HS256"}`, `{"exp":1767225600000}`), exp},
+		{"fractional", makeJWT(`{"alg":"HS256"}`, `{"exp":1767225600.9}`), exp},
+		{"string exp", makeJWT(`{"alg":"HS256"}`, `{"exp":"1767225600"}`), exp},
+		{"url escaped", url.QueryEscape("Bearer " + token), exp},
+		{"quoted", `"` + token + `"`, exp},
+		{"padded segments", token[:len(token)-len(".c2lnbmF0dXJl")] + "==.c2lnbmF0dXJl", exp},
+		{"no exp", makeJWT(`{"alg":"HS256"}`, `{"sub":"user"}`), 0},
+		{"zero exp", makeJWT(`{"alg":"HS256"}`, `{"exp":0}`), 0},
+		{"no alg", makeJWT(`{"typ":"JWT"}`, `{"exp":1767225600}`), 0},
+		{"two segments", "eyJhbGciOiJIUzI1NiJ9.eyJleHAiOjF9", 0},
+		{"not base64", "a.b.c", 0},
+		{"opaque token", "3f2a9c0d-8e1b-4a6f", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseJWTExpiry(tt.value)
+			if tt.want == 0 {
+				if !got.IsZero() {
+					t.Errorf("parseJWTExpiry() = %v, want zero", got)
+				}
+				return
+			}
+			if got.Unix() != tt.want {
+				t.Errorf("parseJWTExpiry() = %v, want %v", got, time.Unix(tt.want, 0))
+			}
+		})
+	}
+}
+
+func TestExpiryTime(t *testing.T) {
+	updatedAt := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	attribute := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	authToken := makeJWT(`{"alg":"HS256"}`, `{"exp":1767225600}`)  // 2026-01-01
+	otherToken := makeJWT(`{"alg":"HS256"}`, `{"exp":1764547200}`) // 2025-12-01
+
+	tests := []struct {
+		name      string
+		cookies   string
+		expiresAt time.Time
+		validDays int
+		want      time.Time
+		source    string
+	}{
+		{"auth cookie jwt first", "other=" + otherToken + "; X-LX-Token=" + authToken, attribute, 30,
+			time.Unix(1767225600, 0), ExpirySourceJWT},
+		{"earliest jwt without auth cookie", "a=" + authToken + "; b=" + otherToken, time.Time{}, 0,
+			time.Unix(1764547200, 0), ExpirySourceJWT},
+		{"cookie attribute", "sid=abc", attribute, 30, attribute, ExpirySourceAttribute},
+		{"valid days", "sid=abc", time.Time{}, 30, updatedAt.AddDate(0, 0, 30), ExpirySourceValidDays},
+		{"unknown", "sid=abc", time.Time{}, 0, time.Time{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := NewManager(tt.cookies, nil, tt.validDays, updatedAt)
+			cm.ExpiresAt = tt.expiresAt
+
+			got, source := cm.ExpiryTime()
+			if !got.Equal(tt.want) || source != tt.source {
+				t.Errorf("ExpiryTime() = %v, %q; want %v, %q", got, source, tt.want, tt.source)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package cookie
+
+import (
+	"crypto/rand"
+	"fmt"
+	mrand "math/rand"
+	"strings"
+)
+
+// 请求头配置的轮换方式
+const (
+	RotationNone       = "none"        // 始终使用第一个配置
+	RotationRoundRobin = "round_robin" // 每次请求依次切换
+	RotationRandom     = "random"      // 每次请求随机选择
+)
+
+// TraceIDHeader 每次请求都会重新生成的追踪 ID 请求头
+const TraceIDHeader = "x-chj-traceid"
+
+// HeaderProfile 一组模拟浏览器指纹的请求头（user-agent、sec-ch-ua、x-chj-* 等）
+type HeaderProfile struct {
+	Name    string            `mapstructure:"name"`
+	Headers map[string]string `mapstructure:"headers"`
+}
+
+// BaseHeaders 与浏览器指纹无关、所有请求共用的请求头
+func BaseHeaders() map[string]string {
+	return map[string]string{
+		"accept":          "application/json, text/plain, */*",
+		"accept-language": "en-US,en;q=0.9,zh-CN;q=0.8,zh-TW;q=0.7,zh;q=0.6",
+		"origin":          "https://www.lixiang.com",
+		"priority":        "u=1, i",
+		"referer":         "https://www.lixiang.com/",
+		"sec-fetch-dest":  "empty",
+		"sec-fetch-mode":  "cors",
+		"sec-fetch-site":  "same-site",
+	}
+}
+
+// DefaultProfile 未配置 header_profiles 时使用的请求头配置（macOS 上的 Chrome 141）
+func DefaultProfile() HeaderProfile {
+	return HeaderProfile{
+		Name: "chrome-141-macos",
+		Headers: map[string]string{
+			"sec-ch-ua":          `"Google Chrome";v="141", "Not?A_Brand";v="8", "Chromium";v="141"`,
+			"sec-ch-ua-mobile":   "?0",
+			"sec-ch-ua-platform": `"macOS"`,
+			"user-agent":         "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36",
+			"x-chj-devicetype":   "1",
+			"x-chj-metadata":     `{"code":"102002"}`,
+			"x-chj-sourceurl":    "https://www.lixiang.com/?chjchannelcode=102002",
+		},
+	}
+}
+
+// ValidRotation 判断轮换方式是否有效
+func ValidRotation(rotation string) bool {
+	switch rotation {
+	case "", RotationNone, RotationRoundRobin, RotationRandom:
+		return true
+	}
+	return false
+}
+
+// SetProfiles 更新请求头配置和轮换方式，profiles 为空时使用 DefaultProfile
+func (cm *Manager) SetProfiles(profiles []HeaderProfile, rotation string) {
+	cm.profileMu.Lock()
+	defer cm.profileMu.Unlock()
+
+	if len(profiles) == 0 {
+		profiles = []HeaderProfile{DefaultProfile()}
+	}
+	cm.profiles = profiles
+	cm.rotation = rotation
+	cm.nextProfile = 0
+}
+
+// requestHeaders 生成本次请求使用的请求头：公共请求头 + 选中的配置 + 新的追踪 ID
+func (cm *Manager) requestHeaders() map[string]string {
+	profile := cm.pickProfile()
+
+	cm.mu.Lock()
+	headers := make(map[string]string, len(cm.Headers)+len(profile.Headers)+1)
+	for key, value := range cm.Headers {
+		headers[key] = value
+	}
+	cm.mu.Unlock()
+	for key, value := range profile.Headers {
+		headers[strings.ToLower(key)] = value
+	}
+	headers[TraceIDHeader] = NewTraceID()
+	return headers
+}
+
+// pickProfile 按轮换方式选择本次请求使用的配置
+func (cm *Manager) pickProfile() HeaderProfile {
+	cm.profileMu.Lock()
+	defer cm.profileMu.Unlock()
+
+	if len(cm.profiles) == 0 {
+		return DefaultProfile()
+	}
+
+	switch cm.rotation {
+	case RotationRoundRobin:
+		profile := cm.profiles[cm.nextProfile%len(cm.profiles)]
+		cm.nextProfile = (cm.nextProfile + 1) % len(cm.profiles)
+		return profile
+	case RotationRandom:
+		return cm.profiles[mrand.Intn(len(cm.profiles))]
+	default:
+		return cm.profiles[0]
+	}
+}
+
+// NewTraceID 生成 UUID v4 格式的追踪 ID
+func NewTraceID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		for i := range b {
+			b[i] = byte(mrand.Intn(256))
+		}
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,371 @@
+package cookie
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Domain 理想汽车 Cookie 所属域名
+const Domain = "lixiang.com"
+
+// AuthCookieNames 会话/认证相关的 Cookie 名称，按优先级排列
+var AuthCookieNames = []string{"X-LX-Token", "X-LX-Deviceid", "authli_device_id"}
+
+// ErrNotExport 输入不是可识别的浏览器导出格式（通常是普通的 Cookie 请求头字符串）
+var ErrNotExport = errors.New("不是可识别的 Cookie 导出格式")
+
+// Export 从浏览器导出文件中解析出的 Cookie
+type Export struct {
+	Format    string         // netscape / json / har
+	Cookies   []*http.Cookie // 已按域名过滤并去重
+	UpdatedAt time.Time      // 导出数据的时间，无法确定时为零值
+}
+
+// ParseExport 自动识别并解析 Netscape cookies.txt、浏览器扩展导出的 JSON 或 HAR 文件
+// 只保留 lixiang.com 域名下的 Cookie
+func ParseExport(data []byte) (*Export, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, ErrNotExport
+	}
+
+	var export *Export
+	var err error
+
+	switch {
+	case trimmed[0] == '{' || trimmed[0] == '[':
+		export, err = parseJSONExport(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("# Netscape HTTP Cookie File")) ||
+		bytes.HasPrefix(trimmed, []byte("# HTTP Cookie File")) ||
+		looksLikeNetscape(trimmed):
+		export, err = parseNetscape(trimmed)
+	default:
+		return nil, ErrNotExport
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	export.Cookies = filterDomain(export.Cookies)
+	if len(export.Cookies) == 0 {
+		return nil, fmt.Errorf("未找到 %s 域名下的 Cookie", Domain)
+	}
+
+	return export, nil
+}
+
+// Header 生成 Cookie 请求头字符串
+func (e *Export) Header() string {
+	parts := make([]string, 0, len(e.Cookies))
+	for _, c := range e.Cookies {
+		parts = append(parts, c.Name+"="+c.Value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ExpiresAt 返回认证 Cookie 的过期时间
+// 优先使用 AuthCookieNames 中的 Cookie，否则取所有带过期时间的 Cookie 中最早的一个，均无时返回零值
+func (e *Export) ExpiresAt() time.Time {
+	for _, name := range AuthCookieNames {
+		for _, c := range e.Cookies {
+			if c.Name == name && !c.Expires.IsZero() {
+				return c.Expires
+			}
+		}
+	}
+
+	var earliest time.Time
+	for _, c := range e.Cookies {
+		if !c.Expires.IsZero() && (earliest.IsZero() || c.Expires.Before(earliest)) {
+			earliest = c.Expires
+		}
+	}
+	return earliest
+}
+
+// looksLikeNetscape 判断是否为不带文件头的 cookies.txt（7 列，以 Tab 分隔）
+func looksLikeNetscape(data []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || (strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "#HttpOnly_")) {
+			continue
+		}
+		return len(strings.Split(line, "\t")) == 7
+	}
+	return false
+}
+
+// parseNetscape 解析 Netscape cookies.txt
+// 每行格式: domain, include_subdomains, path, secure, expiry, name, value
+func parseNetscape(data []byte) (*Export, error) {
+	export := &Export{Format: "netscape"}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := false
+		if strings.HasPrefix(line, "#HttpOnly_") {
+			line = strings.TrimPrefix(line, "#HttpOnly_")
+			httpOnly = true
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("cookies.txt 格式错误: %s", line)
+		}
+
+		c := &http.Cookie{
+			Domain:   fields[0],
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+			HttpOnly: httpOnly,
+		}
+		if expiry, err := strconv.ParseInt(fields[4], 10, 64); err == nil && expiry > 0 {
+			c.Expires = time.Unix(expiry, 0)
+		}
+		export.Cookies = append(export.Cookies, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取 cookies.txt 失败: %v", err)
+	}
+
+	return export, nil
+}
+
+// jsonCookie 浏览器扩展（EditThisCookie、Cookie-Editor 等）及 Puppeteer/Selenium 导出的 Cookie
+type jsonCookie struct {
+	Name           string          `json:"name"`
+	Value          string          `json:"value"`
+	Domain         string          `json:"domain"`
+	Path           string          `json:"path"`
+	Secure         bool            `json:"secure"`
+	HTTPOnly       bool            `json:"httpOnly"`
+	Session        bool            `json:"session"`
+	ExpirationDate float64         `json:"expirationDate"` // 秒，可带小数
+	Expires        json.RawMessage `json:"expires"`        // 秒或时间字符串，-1 表示会话 Cookie
+	Expiry         float64         `json:"expiry"`         // Selenium 格式
+}
+
+// harFile HAR 文件中用到的字段
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			StartedDateTime string `json:"startedDateTime"`
+			Request         struct {
+				URL     string      `json:"url"`
+				Cookies []harCookie `json:"cookies"`
+			} `json:"request"`
+			Response struct {
+				Cookies []harCookie `json:"cookies"`
+			} `json:"response"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// harCookie HAR 中的 Cookie
+type harCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Domain   string `json:"domain"`
+	Path     string `json:"path"`
+	Expires  string `json:"expires"`
+	HTTPOnly bool   `json:"httpOnly"`
+	Secure   bool   `json:"secure"`
+}
+
+// parseJSONExport 解析 JSON 数组、{"cookies": [...]} 或 HAR 文件
+func parseJSONExport(data []byte) (*Export, error) {
+	if data[0] == '{' {
+		var probe map[string]json.RawMessage
+		if err := json.Unmarshal(data, &probe); err != nil {
+			return nil, fmt.Errorf("解析 JSON 失败: %v", err)
+		}
+		if _, ok := probe["log"]; ok {
+			return parseHAR(data)
+		}
+		raw, ok := probe["cookies"]
+		if !ok {
+			// JSON 不可能是 Cookie 请求头，不能当作 ErrNotExport 原样保存
+			return nil, fmt.Errorf("无法识别的 JSON 格式: 需要 Cookie 数组、{\"cookies\": [...]} 或 HAR 文件")
+		}
+		data = raw
+	}
+
+	var items []jsonCookie
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("解析 JSON Cookie 列表失败: %v", err)
+	}
+
+	export := &Export{Format: "json"}
+	for _, item := range items {
+		c := &http.Cookie{
+			Name:     item.Name,
+			Value:    item.Value,
+			Domain:   item.Domain,
+			Path:     item.Path,
+			Secure:   item.Secure,
+			HttpOnly: item.HTTPOnly,
+		}
+		if !item.Session {
+			c.Expires = jsonCookieExpiry(item)
+		}
+		export.Cookies = append(export.Cookies, c)
+	}
+
+	return export, nil
+}
+
+// jsonCookieExpiry 从不同导出工具的字段中读取过期时间
+func jsonCookieExpiry(item jsonCookie) time.Time {
+	if item.ExpirationDate > 0 {
+		return unixFloat(item.ExpirationDate)
+	}
+	if item.Expiry > 0 {
+		return unixFloat(item.Expiry)
+	}
+	if len(item.Expires) == 0 {
+		return time.Time{}
+	}
+
+	var seconds float64
+	if err := json.Unmarshal(item.Expires, &seconds); err == nil {
+		if seconds > 0 {
+			return unixFloat(seconds)
+		}
+		return time.Time{}
+	}
+
+	var text string
+	if err := json.Unmarshal(item.Expires, &text); err == nil {
+		return parseExpiresString(text)
+	}
+	return time.Time{}
+}
+
+// parseHAR 解析 HAR 文件，请求和响应中的 Cookie 按时间顺序合并，后出现的覆盖先出现的
+func parseHAR(data []byte) (*Export, error) {
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("解析 HAR 失败: %v", err)
+	}
+
+	entries := har.Log.Entries
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime < entries[j].StartedDateTime
+	})
+
+	export := &Export{Format: "har"}
+	for _, entry := range entries {
+		// HAR 请求中的 Cookie 通常不带域名，使用请求地址的主机名
+		host := ""
+		if u, err := url.Parse(entry.Request.URL); err == nil {
+			host = u.Hostname()
+		}
+		if !matchDomain(host) {
+			continue
+		}
+
+		found := false
+		for _, list := range [][]harCookie{entry.Request.Cookies, entry.Response.Cookies} {
+			for _, item := range list {
+				domain := item.Domain
+				if domain == "" {
+					domain = host
+				}
+				export.Cookies = append(export.Cookies, &http.Cookie{
+					Name:     item.Name,
+					Value:    item.Value,
+					Domain:   domain,
+					Path:     item.Path,
+					Secure:   item.Secure,
+					HttpOnly: item.HTTPOnly,
+					Expires:  parseExpiresString(item.Expires),
+				})
+				found = true
+			}
+		}
+
+		if found {
+			if t, err := time.Parse(time.RFC3339Nano, entry.StartedDateTime); err == nil && t.After(export.UpdatedAt) {
+				export.UpdatedAt = t
+			}
+		}
+	}
+
+	return export, nil
+}
+
+// filterDomain 只保留 lixiang.com 域名下的 Cookie，同名 Cookie 保留最后一个
+func filterDomain(cookies []*http.Cookie) []*http.Cookie {
+	var result []*http.Cookie
+	index := make(map[string]int)
+
+	for _, c := range cookies {
+		if c.Name == "" || !matchDomain(c.Domain) {
+			continue
+		}
+		// 响应中用于删除 Cookie 的空值/已过期项
+		if c.Value == "" || (!c.Expires.IsZero() && c.Expires.Before(time.Now())) {
+			if i, ok := index[c.Name]; ok {
+				result[i] = nil
+				delete(index, c.Name)
+			}
+			continue
+		}
+
+		if i, ok := index[c.Name]; ok {
+			result[i] = c
+			continue
+		}
+		index[c.Name] = len(result)
+		result = append(result, c)
+	}
+
+	filtered := result[:0]
+	for _, c := range result {
+		if c != nil {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// matchDomain 判断域名是否属于 lixiang.com
+func matchDomain(domain string) bool {
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	return domain == Domain || strings.HasSuffix(domain, "."+Domain)
+}
+
+// parseExpiresString 解析 ISO 8601 或 HTTP 日期格式的过期时间
+func parseExpiresString(text string) time.Time {
+	if text == "" {
+		return time.Time{}
+	}
+	for _, layout := range []string{time.RFC3339Nano, time.RFC1123, "Mon, 02-Jan-2006 15:04:05 MST"} {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// unixFloat 将带小数的秒数转换为时间
+func unixFloat(seconds float64) time.Time {
+	return time.Unix(int64(seconds), int64((seconds-float64(int64(seconds)))*1e9))
+}
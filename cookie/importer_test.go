@@ -0,0 +1,154 @@
+package cookie
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseExport(t *testing.T) {
+	const future = 4102444800 // 2100-01-01
+
+	tests := []struct {
+		name      string
+		input     string
+		format    string
+		header    string
+		expiresAt int64 // Unix 秒，0 表示无过期时间
+		updatedAt string
+		notExport bool // 期望 ErrNotExport（按 Cookie 请求头处理）
+		wantErr   bool // 期望其他错误
+	}{
+		{
+			name: "netscape with header",
+			input: "# Netscape HTTP Cookie File\n" +
+				".lixiang.com\tTRUE\t/\tTRUE\t4102444800\tX-LX-Token\ttok\n" +
+				"#HttpOnly_.lixiang.com\tTRUE\t/\tFALSE\t0\tsid\tabc\n" +
+				".example.com\tTRUE\t/\tFALSE\t0\tother\tx\n",
+			format:    "netscape",
+			header:    "X-LX-Token=tok; sid=abc",
+			expiresAt: future,
+		},
+		{
+			name:   "netscape without header",
+			input:  "www.lixiang.com\tFALSE\t/\tFALSE\t0\tsid\tabc\r\n",
+			format: "netscape",
+			header: "sid=abc",
+		},
+		{
+			name:    "netscape wrong column count",
+			input:   "# Netscape HTTP Cookie File\n.lixiang.com\tTRUE\t/\tsid\n",
+			wantErr: true,
+		},
+		{
+			name: "json array",
+			input: `[
+				{"name":"sid","value":"abc","domain":".lixiang.com","expirationDate":4102444800.5},
+				{"name":"X-LX-Token","value":"tok","domain":"www.lixiang.com","session":true,"expirationDate":4102444800}
+			]`,
+			format:    "json",
+			header:    "sid=abc; X-LX-Token=tok",
+			expiresAt: future,
+		},
+		{
+			name:      "json cookies object with selenium expiry",
+			input:     `{"cookies":[{"name":"sid","value":"abc","domain":"lixiang.com","expiry":4102444800}]}`,
+			format:    "json",
+			header:    "sid=abc",
+			expiresAt: future,
+		},
+		{
+			name: "duplicates keep the last value and expired cookies are dropped",
+			input: `[
+				{"name":"sid","value":"old","domain":".lixiang.com"},
+				{"name":"stale","value":"x","domain":".lixiang.com","expirationDate":946684800},
+				{"name":"sid","value":"new","domain":".lixiang.com"}
+			]`,
+			format: "json",
+			header: "sid=new",
+		},
+		{
+			name: "har",
+			input: `{"log":{"entries":[
+				{"startedDateTime":"2025-10-02T08:00:00.000Z",
+				 "request":{"url":"https://api-web.lixiang.com/a","cookies":[{"name":"sid","value":"new"}]},
+				 "response":{"cookies":[]}},
+				{"startedDateTime":"2025-10-01T08:00:00.000Z",
+				 "request":{"url":"https://www.lixiang.com/","cookies":[{"name":"sid","value":"old"},{"name":"X-LX-Token","value":"tok"}]},
+				 "response":{"cookies":[]}},
+				{"startedDateTime":"2025-10-03T08:00:00.000Z",
+				 "request":{"url":"https://cdn.example.com/x","cookies":[{"name":"other","value":"x"}]},
+				 "response":{"cookies":[]}}
+			]}}`,
+			format:    "har",
+			header:    "sid=new; X-LX-Token=tok",
+			updatedAt: "2025-10-02T08:00:00Z",
+		},
+		{
+			name:      "cookie header",
+			input:     "sid=abc; X-LX-Token=tok",
+			notExport: true,
+		},
+		{
+			name:      "empty",
+			input:     "  \n",
+			notExport: true,
+		},
+		{
+			name:    "unrecognised json object",
+			input:   `{"token":"abc"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json array",
+			input:   `[{"name":`,
+			wantErr: true,
+		},
+		{
+			name:    "no lixiang cookies",
+			input:   `[{"name":"other","value":"x","domain":".example.com"}]`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			export, err := ParseExport([]byte(tt.input))
+			switch {
+			case tt.notExport:
+				if !errors.Is(err, ErrNotExport) {
+					t.Fatalf("ParseExport() error = %v, want ErrNotExport", err)
+				}
+				return
+			case tt.wantErr:
+				if err == nil || errors.Is(err, ErrNotExport) {
+					t.Fatalf("ParseExport() error = %v, want a parse error", err)
+				}
+				return
+			case err != nil:
+				t.Fatalf("ParseExport() error = %v", err)
+			}
+
+			if export.Format != tt.format {
+				t.Errorf("Format = %q, want %q", export.Format, tt.format)
+			}
+			if got := export.Header(); got != tt.header {
+				t.Errorf("Header() = %q, want %q", got, tt.header)
+			}
+
+			expiresAt := export.ExpiresAt()
+			if tt.expiresAt == 0 && !expiresAt.IsZero() {
+				t.Errorf("ExpiresAt() = %v, want zero", expiresAt)
+			} else if tt.expiresAt != 0 && expiresAt.Unix() != tt.expiresAt {
+				t.Errorf("ExpiresAt() = %v, want %v", expiresAt, time.Unix(tt.expiresAt, 0))
+			}
+
+			if tt.updatedAt != "" {
+				want, _ := time.Parse(time.RFC3339, tt.updatedAt)
+				if !export.UpdatedAt.Equal(want) {
+					t.Errorf("UpdatedAt = %v, want %v", export.UpdatedAt, want)
+				}
+			}
+		})
+	}
+}
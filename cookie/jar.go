@@ -0,0 +1,144 @@
+package cookie
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Jar 理想汽车站点的 Cookie 容器，实现 http.CookieJar 接口
+// 只保存 lixiang.com 域名下的 Cookie，以名称为键并保留原有顺序，便于写回配置
+type Jar struct {
+	mu      sync.Mutex
+	names   []string
+	cookies map[string]*http.Cookie
+	changed bool
+}
+
+// NewJar 使用 Cookie 请求头字符串初始化 Cookie 容器
+func NewJar(header string) *Jar {
+	j := &Jar{}
+	j.Reset(header)
+	return j
+}
+
+// Reset 丢弃当前内容并重新使用 Cookie 请求头字符串初始化
+func (j *Jar) Reset(header string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.names = nil
+	j.cookies = make(map[string]*http.Cookie)
+	j.changed = false
+
+	for _, part := range strings.Split(header, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || name == "" {
+			continue
+		}
+		if _, exists := j.cookies[name]; !exists {
+			j.names = append(j.names, name)
+		}
+		j.cookies[name] = &http.Cookie{Name: name, Value: value}
+	}
+}
+
+// SetCookies 实现 http.CookieJar 接口，处理响应中的 Set-Cookie
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if !matchDomain(u.Hostname()) {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	for _, c := range cookies {
+		if c.Domain != "" && !matchDomain(c.Domain) {
+			continue
+		}
+
+		// MaxAge < 0 或过期时间早于当前时间表示删除
+		if c.MaxAge < 0 || (!c.Expires.IsZero() && c.Expires.Before(now)) {
+			if _, ok := j.cookies[c.Name]; ok {
+				delete(j.cookies, c.Name)
+				j.changed = true
+			}
+			continue
+		}
+
+		expires := c.Expires
+		if c.MaxAge > 0 {
+			expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		}
+
+		existing, ok := j.cookies[c.Name]
+		if !ok {
+			j.names = append(j.names, c.Name)
+		}
+		if !ok || existing.Value != c.Value || !existing.Expires.Equal(expires) {
+			j.changed = true
+		}
+		j.cookies[c.Name] = &http.Cookie{Name: c.Name, Value: c.Value, Expires: expires}
+	}
+}
+
+// Cookies 实现 http.CookieJar 接口，返回请求需要携带的 Cookie
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	if !matchDomain(u.Hostname()) {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	result := make([]*http.Cookie, 0, len(j.cookies))
+	for _, name := range j.names {
+		c, ok := j.cookies[name]
+		if !ok || (!c.Expires.IsZero() && c.Expires.Before(now)) {
+			continue
+		}
+		result = append(result, &http.Cookie{Name: c.Name, Value: c.Value})
+	}
+	return result
+}
+
+// Header 生成 Cookie 请求头字符串
+func (j *Jar) Header() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	parts := make([]string, 0, len(j.cookies))
+	for _, name := range j.names {
+		if c, ok := j.cookies[name]; ok {
+			parts = append(parts, c.Name+"="+c.Value)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// AuthExpiry 返回服务端为认证 Cookie 设置的过期时间，未设置时返回零值
+func (j *Jar) AuthExpiry() time.Time {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, name := range AuthCookieNames {
+		if c, ok := j.cookies[name]; ok && !c.Expires.IsZero() {
+			return c.Expires
+		}
+	}
+	return time.Time{}
+}
+
+// TakeChanged 返回自上次调用以来服务端是否更新过 Cookie，并清除标记
+func (j *Jar) TakeChanged() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	changed := j.changed
+	j.changed = false
+	return changed
+}
@@ -0,0 +1,74 @@
+package cookie
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State 服务端刷新后的 Cookie 状态，保存在独立的状态文件中
+type State struct {
+	Cookies   string    `json:"cookies"`
+	Origin    string    `json:"origin"` // 派生自的配置 Cookie 的摘要，配置中的 Cookie 变化后状态失效
+	UpdatedAt time.Time `json:"updated_at"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// OriginHash 计算配置中 Cookie 的摘要
+func OriginHash(configCookies string) string {
+	sum := sha256.Sum256([]byte(configCookies))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadState 读取 Cookie 状态文件，文件不存在时返回 nil
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取 Cookie 状态文件失败: %v", err)
+	}
+
+	state := &State{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("解析 Cookie 状态文件失败: %v", err)
+	}
+	return state, nil
+}
+
+// SaveState 写入 Cookie 状态文件（仅当前用户可读写）
+func SaveState(path string, state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化 Cookie 状态失败: %v", err)
+	}
+
+	// 先写临时文件再重命名，避免写入中断导致文件损坏
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cookie-state-*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入 Cookie 状态文件失败: %v", err)
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("设置文件权限失败: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入 Cookie 状态文件失败: %v", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("保存 Cookie 状态文件失败: %v", err)
+	}
+	return nil
+}
@@ -1,9 +1,12 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"net/url"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite" // 纯 Go 实现的 SQLite 驱动，无需 CGO
@@ -24,15 +27,46 @@ type DeliveryRecord struct {
 	CreatedAt        time.Time `json:"created_at"`
 }
 
+// CheckFailure 检查失败记录
+type CheckFailure struct {
+	ID        int       `json:"id"`
+	OrderID   string    `json:"order_id"`
+	Kind      string    `json:"kind"`    // 失败类型
+	Message   string    `json:"message"` // 错误信息
+	CheckTime time.Time `json:"check_time"`
+}
+
+// 检查失败类型
+const (
+	FailureCookieExpired = "cookie_expired"
+	FailureAPIError      = "api_error"
+	FailureKeepalive     = "cookie_keepalive"
+)
+
+// PeriodSummary 时间段内的检查汇总
+type PeriodSummary struct {
+	Checks         int               // 成功检查次数
+	Notifications  int               // 发送通知次数
+	Failures       int               // 失败检查次数
+	FailuresByKind map[string]int    // 按类型统计的失败次数
+	Changes        []*DeliveryRecord // 时间变更记录（按时间正序）
+	FirstRecord    *DeliveryRecord   // 时间段内第一条记录
+	LastRecord     *DeliveryRecord   // 时间段内最后一条记录
+}
+
 // Database 数据库管理器
 type Database struct {
 	db *sql.DB
 }
 
+// busyTimeoutPragma 数据库被其他连接或进程锁定时等待的最长时间（毫秒）
+// 常驻服务和外部调度的 once 可能同时读写同一个数据库，不设置时会立即返回 SQLITE_BUSY
+const busyTimeoutPragma = "_pragma=busy_timeout(10000)"
+
 // New 创建数据库实例
 func New(dbPath string) (*Database, error) {
-	// 打开数据库连接
-	db, err := sql.Open("sqlite", dbPath)
+	// 打开数据库连接，查询参数由驱动解析，不会成为文件名的一部分
+	db, err := sql.Open("sqlite", dbPath+"?"+busyTimeoutPragma)
 	if err != nil {
 		return nil, fmt.Errorf("打开数据库失败: %w", err)
 	}
@@ -44,39 +78,29 @@ func New(dbPath string) (*Database, error) {
 
 	database := &Database{db: db}
 
-	// 初始化表结构
-	if err := database.initTables(); err != nil {
-		return nil, fmt.Errorf("初始化表结构失败: %w", err)
+	// 执行数据库迁移
+	if err := database.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("数据库迁移失败: %w", err)
 	}
 
 	log.Printf("[DB] 数据库初始化成功: %s", dbPath)
 	return database, nil
 }
 
-// initTables 初始化数据库表
-func (d *Database) initTables() error {
-	createTableSQL := `
-	CREATE TABLE IF NOT EXISTS delivery_records (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		order_id TEXT NOT NULL,
-		estimate_time TEXT NOT NULL,
-		lock_order_time DATETIME NOT NULL,
-		check_time DATETIME NOT NULL,
-		is_approaching BOOLEAN NOT NULL DEFAULT 0,
-		approach_message TEXT,
-		time_changed BOOLEAN NOT NULL DEFAULT 0,
-		previous_estimate TEXT,
-		notification_sent BOOLEAN NOT NULL DEFAULT 0,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_order_id ON delivery_records(order_id);
-	CREATE INDEX IF NOT EXISTS idx_check_time ON delivery_records(check_time);
-	CREATE INDEX IF NOT EXISTS idx_created_at ON delivery_records(created_at);
-	`
+// OpenReadOnly 以只读方式打开已有的数据库，不创建文件也不执行迁移，供只读取记录的命令行工具使用
+func OpenReadOnly(dbPath string) (*Database, error) {
+	dsn := "file:" + (&url.URL{Path: dbPath}).String() + "?mode=ro&" + busyTimeoutPragma
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("打开数据库失败: %w", err)
+	}
 
-	_, err := d.db.Exec(createTableSQL)
-	return err
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("连接数据库失败: %w", err)
+	}
+	return &Database{db: db}, nil
 }
 
 // SaveDeliveryRecord 保存交付记录
@@ -205,6 +229,134 @@ func (d *Database) GetRecordsCount(orderID string) (int, error) {
 	return count, nil
 }
 
+// RecordFilter 交付记录查询条件，零值字段不参与过滤
+type RecordFilter struct {
+	OrderID          string
+	Since            time.Time // 检查时间 >= Since
+	Until            time.Time // 检查时间 < Until
+	TimeChanged      *bool
+	NotificationSent *bool
+	IsApproaching    *bool
+
+	// 分页：结果按检查时间倒序排列。AfterID 为上一页最后一条记录的 ID（游标分页），
+	// 设置后忽略 Offset
+	AfterID int
+	Offset  int
+	Limit   int // 小于等于 0 时不限制
+}
+
+// where 构造过滤条件（不含分页）
+func (f RecordFilter) where() (string, []interface{}) {
+	conditions := []string{"order_id = ?"}
+	args := []interface{}{f.OrderID}
+
+	// check_time 以写入时的本地时间文本保存，比较前统一转换为本地时区
+	if !f.Since.IsZero() {
+		conditions = append(conditions, "check_time >= ?")
+		args = append(args, f.Since.Local())
+	}
+	if !f.Until.IsZero() {
+		conditions = append(conditions, "check_time < ?")
+		args = append(args, f.Until.Local())
+	}
+	flags := []struct {
+		column string
+		value  *bool
+	}{
+		{"time_changed", f.TimeChanged},
+		{"notification_sent", f.NotificationSent},
+		{"is_approaching", f.IsApproaching},
+	}
+	for _, flag := range flags {
+		if flag.value != nil {
+			conditions = append(conditions, flag.column+" = ?")
+			args = append(args, *flag.value)
+		}
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// FindRecords 按条件查询交付记录（按检查时间倒序）
+func (d *Database) FindRecords(filter RecordFilter) ([]*DeliveryRecord, error) {
+	where, args := filter.where()
+
+	if filter.AfterID > 0 {
+		where += " AND (check_time, id) < (SELECT check_time, id FROM delivery_records WHERE id = ?)"
+		args = append(args, filter.AfterID)
+	}
+
+	query := `
+	SELECT id, order_id, estimate_time, lock_order_time, check_time,
+		   is_approaching, approach_message, time_changed,
+		   previous_estimate, notification_sent, created_at
+	FROM delivery_records
+	WHERE ` + where + `
+	ORDER BY check_time DESC, id DESC
+	LIMIT ? OFFSET ?
+	`
+
+	limit, offset := filter.Limit, filter.Offset
+	if limit <= 0 {
+		limit = -1
+	}
+	if filter.AfterID > 0 || offset < 0 {
+		offset = 0
+	}
+	args = append(args, limit, offset)
+
+	return d.queryRecords(query, args...)
+}
+
+// CountRecords 统计符合条件的交付记录数（忽略分页参数）
+func (d *Database) CountRecords(filter RecordFilter) (int, error) {
+	where, args := filter.where()
+
+	var count int
+	if err := d.db.QueryRow("SELECT COUNT(*) FROM delivery_records WHERE "+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("查询记录数失败: %w", err)
+	}
+	return count, nil
+}
+
+// RecordStats 交付记录统计
+type RecordStats struct {
+	Total            int       // 记录总数
+	TimeChanged      int       // 时间变化次数
+	NotificationSent int       // 发送通知次数
+	FirstCheckTime   time.Time // 第一次检查时间，没有记录时为零值
+}
+
+// GetRecordStats 使用 SQL 聚合统计指定订单的交付记录
+func (d *Database) GetRecordStats(orderID string) (*RecordStats, error) {
+	stats := &RecordStats{}
+
+	err := d.db.QueryRow(`
+	SELECT COUNT(*), COALESCE(SUM(time_changed), 0), COALESCE(SUM(notification_sent), 0)
+	FROM delivery_records
+	WHERE order_id = ?
+	`, orderID).Scan(&stats.Total, &stats.TimeChanged, &stats.NotificationSent)
+	if err != nil {
+		return nil, fmt.Errorf("统计记录失败: %w", err)
+	}
+	if stats.Total == 0 {
+		return stats, nil
+	}
+
+	// MIN() 的结果没有列类型，驱动无法解析为时间，因此按索引取第一条记录
+	err = d.db.QueryRow(`
+	SELECT check_time FROM delivery_records
+	WHERE order_id = ?
+	ORDER BY check_time ASC
+	LIMIT 1
+	`, orderID).Scan(&stats.FirstCheckTime)
+	if err != nil {
+		return nil, fmt.Errorf("查询首次检查时间失败: %w", err)
+	}
+
+	return stats, nil
+}
+
 // GetTimeChangedRecords 获取时间发生变化的记录
 func (d *Database) GetTimeChangedRecords(orderID string, limit int) ([]*DeliveryRecord, error) {
 	query := `
@@ -248,6 +400,196 @@ func (d *Database) GetTimeChangedRecords(orderID string, limit int) ([]*Delivery
 	return records, nil
 }
 
+// SaveCheckFailure 保存检查失败记录
+func (d *Database) SaveCheckFailure(failure *CheckFailure) error {
+	query := `
+	INSERT INTO check_failures (order_id, kind, message, check_time)
+	VALUES (?, ?, ?, ?)
+	`
+
+	_, err := d.db.Exec(query, failure.OrderID, failure.Kind, failure.Message, failure.CheckTime)
+	if err != nil {
+		return fmt.Errorf("保存失败记录失败: %w", err)
+	}
+
+	return nil
+}
+
+// GetPeriodSummary 获取指定时间段内的检查汇总
+func (d *Database) GetPeriodSummary(orderID string, since, until time.Time) (*PeriodSummary, error) {
+	summary := &PeriodSummary{FailuresByKind: make(map[string]int)}
+
+	err := d.db.QueryRow(`
+	SELECT COUNT(*), COALESCE(SUM(notification_sent), 0)
+	FROM delivery_records
+	WHERE order_id = ? AND check_time >= ? AND check_time < ?
+	`, orderID, since, until).Scan(&summary.Checks, &summary.Notifications)
+	if err != nil {
+		return nil, fmt.Errorf("统计检查次数失败: %w", err)
+	}
+
+	rows, err := d.db.Query(`
+	SELECT kind, COUNT(*)
+	FROM check_failures
+	WHERE order_id = ? AND check_time >= ? AND check_time < ?
+	GROUP BY kind
+	`, orderID, since, until)
+	if err != nil {
+		return nil, fmt.Errorf("统计失败次数失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind string
+		var count int
+		if err := rows.Scan(&kind, &count); err != nil {
+			return nil, fmt.Errorf("扫描失败统计失败: %w", err)
+		}
+		summary.FailuresByKind[kind] = count
+		summary.Failures += count
+	}
+
+	records, err := d.queryRecords(`
+	SELECT id, order_id, estimate_time, lock_order_time, check_time,
+		   is_approaching, approach_message, time_changed,
+		   previous_estimate, notification_sent, created_at
+	FROM delivery_records
+	WHERE order_id = ? AND check_time >= ? AND check_time < ?
+		  AND (time_changed = 1 OR id IN (
+			SELECT MIN(id) FROM delivery_records WHERE order_id = ? AND check_time >= ? AND check_time < ?
+			UNION
+			SELECT MAX(id) FROM delivery_records WHERE order_id = ? AND check_time >= ? AND check_time < ?
+		  ))
+	ORDER BY check_time ASC
+	`, orderID, since, until, orderID, since, until, orderID, since, until)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, record := range records {
+		if i == 0 {
+			summary.FirstRecord = record
+		}
+		summary.LastRecord = record
+		if record.TimeChanged {
+			summary.Changes = append(summary.Changes, record)
+		}
+	}
+
+	return summary, nil
+}
+
+// GetLastNotifiedRecord 获取最近一条已发送通知的记录，没有时返回 nil
+func (d *Database) GetLastNotifiedRecord(orderID string) (*DeliveryRecord, error) {
+	query := `
+	SELECT id, order_id, estimate_time, lock_order_time, check_time,
+		   is_approaching, approach_message, time_changed,
+		   previous_estimate, notification_sent, created_at
+	FROM delivery_records
+	WHERE order_id = ? AND notification_sent = 1
+	ORDER BY check_time DESC
+	LIMIT 1
+	`
+
+	records, err := d.queryRecords(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return records[0], nil
+}
+
+// GetEstimateTimeline 获取预计时间的变化节点（按检查时间正序）
+// 包含第一条记录、预计时间与前一条不同的记录以及最新一条记录，用于绘制阶梯图。
+// 与 time_changed 不同，服务重启后的首次检查如果预计时间变化也会计入
+func (d *Database) GetEstimateTimeline(orderID string) ([]*DeliveryRecord, error) {
+	query := `
+	SELECT id, order_id, estimate_time, lock_order_time, check_time,
+		   is_approaching, approach_message, time_changed,
+		   previous_estimate, notification_sent, created_at
+	FROM (
+		SELECT *,
+			   LAG(estimate_time) OVER (ORDER BY check_time, id) AS prev_estimate,
+			   ROW_NUMBER() OVER (ORDER BY check_time DESC, id DESC) AS reverse_rank
+		FROM delivery_records
+		WHERE order_id = ?
+	)
+	WHERE prev_estimate IS NULL OR prev_estimate != estimate_time OR reverse_rank = 1
+	ORDER BY check_time, id
+	`
+
+	return d.queryRecords(query, orderID)
+}
+
+// GetState 读取保存的运行状态，不存在时返回空字符串
+func (d *Database) GetState(key string) (string, error) {
+	var value string
+	err := d.db.QueryRow("SELECT value FROM monitor_state WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("查询运行状态失败: %w", err)
+	}
+	return value, nil
+}
+
+// SaveState 保存运行状态，已存在时覆盖
+func (d *Database) SaveState(key, value string) error {
+	_, err := d.db.Exec(`
+	INSERT INTO monitor_state (key, value, updated_at) VALUES (?, ?, ?)
+	ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+	`, key, value, time.Now().Round(0))
+	if err != nil {
+		return fmt.Errorf("保存运行状态失败: %w", err)
+	}
+	return nil
+}
+
+// queryRecords 执行查询并扫描交付记录
+func (d *Database) queryRecords(query string, args ...interface{}) ([]*DeliveryRecord, error) {
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*DeliveryRecord
+	for rows.Next() {
+		record := &DeliveryRecord{}
+		err := rows.Scan(
+			&record.ID,
+			&record.OrderID,
+			&record.EstimateTime,
+			&record.LockOrderTime,
+			&record.CheckTime,
+			&record.IsApproaching,
+			&record.ApproachMessage,
+			&record.TimeChanged,
+			&record.PreviousEstimate,
+			&record.NotificationSent,
+			&record.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("扫描记录失败: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// Ping 检查数据库是否可以正常读取
+func (d *Database) Ping(ctx context.Context) error {
+	var count int
+	if err := d.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master").Scan(&count); err != nil {
+		return fmt.Errorf("查询数据库失败: %w", err)
+	}
+	return nil
+}
+
 // Close 关闭数据库连接
 func (d *Database) Close() error {
 	if d.db != nil {
@@ -0,0 +1,193 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestDatabase 在临时目录中创建数据库
+func newTestDatabase(t *testing.T) (*Database, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "monitor.db")
+	database, err := New(path)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database, path
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	database, path := newTestDatabase(t)
+	now := time.Now()
+	err := database.SaveDeliveryRecord(&DeliveryRecord{
+		OrderID: "order-1", EstimateTime: "A", LockOrderTime: now, CheckTime: now, CreatedAt: now,
+	})
+	if err != nil {
+		t.Fatalf("SaveDeliveryRecord() error = %v", err)
+	}
+
+	readOnly, err := OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("OpenReadOnly() error = %v", err)
+	}
+	defer readOnly.Close()
+
+	if count, err := readOnly.GetRecordsCount("order-1"); err != nil || count != 1 {
+		t.Errorf("GetRecordsCount() = %d, %v; want 1", count, err)
+	}
+	err = readOnly.SaveDeliveryRecord(&DeliveryRecord{
+		OrderID: "order-1", EstimateTime: "B", LockOrderTime: now, CheckTime: now, CreatedAt: now,
+	})
+	if err == nil {
+		t.Error("SaveDeliveryRecord() succeeded on a read-only database")
+	}
+}
+
+func TestOpenReadOnlyMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.db")
+	if database, err := OpenReadOnly(path); err == nil {
+		database.Close()
+		t.Fatal("OpenReadOnly() succeeded for a missing file")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("OpenReadOnly() created %s", path)
+	}
+}
+
+// seedRecords 为 order-1 写入 10 条每小时一条的记录（第 5、6 条检查时间相同），为 order-2 写入 2 条
+// 返回 order-1 记录的 ID，下标与写入顺序一致
+func seedRecords(t *testing.T, database *Database, base time.Time) []int {
+	t.Helper()
+
+	save := func(orderID string, checkTime time.Time, changed bool) {
+		err := database.SaveDeliveryRecord(&DeliveryRecord{
+			OrderID:       orderID,
+			EstimateTime:  checkTime.Format("15:04"),
+			LockOrderTime: base,
+			CheckTime:     checkTime,
+			TimeChanged:   changed,
+			CreatedAt:     checkTime,
+		})
+		if err != nil {
+			t.Fatalf("SaveDeliveryRecord() error = %v", err)
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		hour := i
+		if i == 6 {
+			hour = 5
+		}
+		save("order-1", base.Add(time.Duration(hour)*time.Hour), i%3 == 0)
+	}
+	save("order-2", base, true)
+	save("order-2", base.Add(time.Hour), false)
+
+	records, err := database.FindRecords(RecordFilter{OrderID: "order-1"})
+	if err != nil {
+		t.Fatalf("FindRecords() error = %v", err)
+	}
+	ids := make([]int, len(records))
+	for i, r := range records {
+		ids[len(records)-1-i] = r.ID
+	}
+	return ids
+}
+
+func TestFindRecords(t *testing.T) {
+	database, _ := newTestDatabase(t)
+	// check_time 以本地时间写入，与程序中 time.Now() 一致
+	base := time.Date(2025, 10, 1, 8, 0, 0, 0, time.Local)
+	ids := seedRecords(t, database, base)
+	changed := true
+	shanghai := time.FixedZone("UTC+8", 8*3600)
+
+	tests := []struct {
+		name   string
+		filter RecordFilter
+		want   []int // 期望记录在写入顺序中的下标
+		count  int   // 期望的 CountRecords 结果
+	}{
+		{"all", RecordFilter{}, []int{9, 8, 7, 6, 5, 4, 3, 2, 1, 0}, 10},
+		{"limit", RecordFilter{Limit: 3}, []int{9, 8, 7}, 10},
+		{"offset", RecordFilter{Limit: 3, Offset: 3}, []int{6, 5, 4}, 10},
+		{"offset past the end", RecordFilter{Limit: 3, Offset: 20}, nil, 10},
+		{"cursor", RecordFilter{Limit: 3, AfterID: ids[7]}, []int{6, 5, 4}, 10},
+		{"cursor ignores offset", RecordFilter{Limit: 3, AfterID: ids[7], Offset: 5}, []int{6, 5, 4}, 10},
+		{"cursor across equal check times", RecordFilter{Limit: 2, AfterID: ids[6]}, []int{5, 4}, 10},
+		{"since inclusive until exclusive", RecordFilter{Since: base.Add(2 * time.Hour), Until: base.Add(5 * time.Hour)}, []int{4, 3, 2}, 3},
+		{"since in another time zone", RecordFilter{Since: base.Add(7 * time.Hour).In(shanghai)}, []int{9, 8, 7}, 3},
+		{"until in UTC", RecordFilter{Until: base.Add(2 * time.Hour).UTC()}, []int{1, 0}, 2},
+		{"time changed", RecordFilter{TimeChanged: &changed}, []int{9, 6, 3, 0}, 4},
+		{"time changed with since", RecordFilter{TimeChanged: &changed, Since: base.Add(time.Hour)}, []int{9, 6, 3}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			filter.OrderID = "order-1"
+
+			records, err := database.FindRecords(filter)
+			if err != nil {
+				t.Fatalf("FindRecords() error = %v", err)
+			}
+			var got []int
+			for _, r := range records {
+				for i, id := range ids {
+					if r.ID == id {
+						got = append(got, i)
+					}
+				}
+				if r.OrderID != "order-1" {
+					t.Errorf("record %d belongs to %s", r.ID, r.OrderID)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindRecords() = %v, want %v", got, tt.want)
+			}
+
+			count, err := database.CountRecords(filter)
+			if err != nil {
+				t.Fatalf("CountRecords() error = %v", err)
+			}
+			if count != tt.count {
+				t.Errorf("CountRecords() = %d, want %d", count, tt.count)
+			}
+		})
+	}
+}
+
+func TestFindRecordsCursorWalk(t *testing.T) {
+	database, _ := newTestDatabase(t)
+	base := time.Date(2025, 10, 1, 8, 0, 0, 0, time.Local)
+	ids := seedRecords(t, database, base)
+
+	// 按游标逐页读取，不重复也不遗漏
+	var walked []int
+	after := 0
+	for page := 0; page < 10; page++ {
+		records, err := database.FindRecords(RecordFilter{OrderID: "order-1", Limit: 4, AfterID: after})
+		if err != nil {
+			t.Fatalf("FindRecords() error = %v", err)
+		}
+		if len(records) == 0 {
+			break
+		}
+		for _, r := range records {
+			walked = append(walked, r.ID)
+		}
+		after = records[len(records)-1].ID
+	}
+
+	want := make([]int, len(ids))
+	for i := range ids {
+		want[i] = ids[len(ids)-1-i]
+	}
+	if !reflect.DeepEqual(walked, want) {
+		t.Errorf("cursor walk = %v, want %v", walked, want)
+	}
+}
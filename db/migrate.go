@@ -0,0 +1,206 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationsFS 内置的数据库迁移脚本，文件名格式为 <版本号>_<说明>.sql，版本号从 1 开始连续递增
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// ErrSchemaTooNew 数据库结构版本高于程序支持的版本，通常是回退到了旧版本程序
+var ErrSchemaTooNew = errors.New("数据库结构版本高于程序支持的版本")
+
+// migration 一个数据库迁移
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// loadMigrations 读取内置的迁移脚本，按版本号排序并检查版本号连续
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移脚本失败: %w", err)
+	}
+
+	var migrations []migration
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("迁移脚本文件名无效: %s", entry.Name())
+		}
+
+		content, err := migrationsFS.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("读取迁移脚本 %s 失败: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, migration{version: version, name: name, sql: string(content)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	for i, m := range migrations {
+		if m.version != i+1 {
+			return nil, fmt.Errorf("迁移脚本版本号不连续: 期望 %d，实际为 %s", i+1, m.name)
+		}
+	}
+	return migrations, nil
+}
+
+// migrate 按顺序执行尚未应用的迁移，每个迁移在独立的事务中执行并记录到 schema_migrations
+// 数据库版本高于内置迁移的最新版本时返回 ErrSchemaTooNew，不做任何修改
+func (d *Database) migrate() error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	// 手动 BEGIN IMMEDIATE 的事务必须在同一个连接上执行
+	ctx := context.Background()
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("创建 schema_migrations 表失败: %w", err)
+	}
+
+	current, err := schemaVersion(ctx, conn)
+	if err != nil {
+		return err
+	}
+	latest := len(migrations)
+	if current > latest {
+		return fmt.Errorf("%w: 数据库为 %d，程序最高支持 %d，请升级程序后再运行", ErrSchemaTooNew, current, latest)
+	}
+
+	for _, m := range migrations[current:] {
+		applied, err := applyMigration(ctx, conn, m)
+		if err != nil {
+			return err
+		}
+		if applied {
+			log.Printf("[DB] 已应用数据库迁移: %s", m.name)
+		}
+	}
+	return nil
+}
+
+// applyMigration 在事务中执行一个迁移，失败时回滚；迁移已被其他进程应用时返回 false
+// 使用 BEGIN IMMEDIATE 在事务开始时就取得写锁，另一个进程会在此等待，
+// 拿到锁后重新读取版本，避免两个进程读到同一个旧版本后重复执行同一个迁移
+func applyMigration(ctx context.Context, conn *sql.Conn, m migration) (bool, error) {
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return false, fmt.Errorf("开始迁移 %s 失败: %w", m.name, err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			conn.ExecContext(ctx, "ROLLBACK")
+		}
+	}()
+
+	current, err := schemaVersion(ctx, conn)
+	if err != nil {
+		return false, err
+	}
+	if current >= m.version {
+		return false, nil
+	}
+
+	if _, err := conn.ExecContext(ctx, m.sql); err != nil {
+		return false, fmt.Errorf("执行迁移 %s 失败: %w", m.name, err)
+	}
+	// Round(0) 去掉单调时钟读数，避免写入 "m=+0.01" 后缀
+	_, err = conn.ExecContext(ctx, "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+		m.version, m.name, time.Now().Round(0))
+	if err != nil {
+		return false, fmt.Errorf("记录迁移 %s 失败: %w", m.name, err)
+	}
+
+	if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+		return false, fmt.Errorf("提交迁移 %s 失败: %w", m.name, err)
+	}
+	committed = true
+	return true, nil
+}
+
+// schemaVersion 在指定连接上查询已应用的最新迁移版本号
+func schemaVersion(ctx context.Context, conn *sql.Conn) (int, error) {
+	var version int
+	if err := conn.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("查询数据库结构版本失败: %w", err)
+	}
+	return version, nil
+}
+
+// CheckSchema 检查已有数据库的结构版本是否被当前程序支持，版本过高时返回 ErrSchemaTooNew
+// 只读打开且不执行迁移，供启动服务前拒绝旧版本程序打开新结构的数据库；数据库不存在时不报错
+func CheckSchema(dbPath string) error {
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	database, err := OpenReadOnly(dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.db.Close()
+
+	// 引入迁移之前创建的数据库没有 schema_migrations 表，启动后会从第一个迁移开始升级
+	var tables int
+	err = database.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'").Scan(&tables)
+	if err != nil {
+		return fmt.Errorf("查询数据库结构失败: %w", err)
+	}
+	if tables == 0 {
+		return nil
+	}
+
+	current, err := database.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if latest := len(migrations); current > latest {
+		return fmt.Errorf("%w: 数据库为 %d，程序最高支持 %d，请升级程序后再运行", ErrSchemaTooNew, current, latest)
+	}
+	return nil
+}
+
+// SchemaVersion 返回数据库当前的结构版本，即已应用的最新迁移版本号
+func (d *Database) SchemaVersion() (int, error) {
+	var version int
+	if err := d.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("查询数据库结构版本失败: %w", err)
+	}
+	return version, nil
+}
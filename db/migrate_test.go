@@ -0,0 +1,168 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations() error = %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Errorf("migrations[%d].version = %d, want %d", i, m.version, i+1)
+		}
+		if m.sql == "" {
+			t.Errorf("migration %s is empty", m.name)
+		}
+	}
+	if migrations[0].name != "0001_initial_schema" {
+		t.Errorf("first migration = %s, want 0001_initial_schema", migrations[0].name)
+	}
+}
+
+// preMigrationSchema 引入迁移之前由程序直接创建的表结构
+const preMigrationSchema = `
+CREATE TABLE delivery_records (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	order_id TEXT NOT NULL,
+	estimate_time TEXT NOT NULL,
+	lock_order_time DATETIME NOT NULL,
+	check_time DATETIME NOT NULL,
+	is_approaching BOOLEAN NOT NULL DEFAULT 0,
+	approach_message TEXT,
+	time_changed BOOLEAN NOT NULL DEFAULT 0,
+	previous_estimate TEXT,
+	notification_sent BOOLEAN NOT NULL DEFAULT 0,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_order_id ON delivery_records(order_id);
+CREATE INDEX idx_check_time ON delivery_records(check_time);
+CREATE INDEX idx_created_at ON delivery_records(created_at);
+CREATE TABLE check_failures (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	order_id TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	message TEXT,
+	check_time DATETIME NOT NULL
+);
+CREATE INDEX idx_failures_check_time ON check_failures(check_time);
+`
+
+func TestMigratePreMigrationDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	legacy, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	if _, err := legacy.Exec(preMigrationSchema); err != nil {
+		t.Fatalf("create legacy schema: %v", err)
+	}
+	now := time.Now()
+	_, err = legacy.Exec(`INSERT INTO delivery_records (order_id, estimate_time, lock_order_time, check_time,
+		approach_message, previous_estimate, created_at) VALUES (?, ?, ?, ?, '', '', ?)`, "order-1", "A", now, now, now)
+	if err != nil {
+		t.Fatalf("insert legacy record: %v", err)
+	}
+	legacy.Close()
+
+	if err := CheckSchema(path); err != nil {
+		t.Fatalf("CheckSchema() on a legacy database = %v", err)
+	}
+
+	database, err := New(path)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer database.Close()
+
+	migrations, _ := loadMigrations()
+	if version, err := database.SchemaVersion(); err != nil || version != len(migrations) {
+		t.Errorf("SchemaVersion() = %d, %v; want %d", version, err, len(migrations))
+	}
+	if latest, err := database.GetLatestRecord("order-1"); err != nil || latest == nil || latest.EstimateTime != "A" {
+		t.Errorf("legacy record lost after migration: %+v, %v", latest, err)
+	}
+
+	var index int
+	err = database.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = 'idx_records_order_check_time'").Scan(&index)
+	if err != nil || index != 1 {
+		t.Errorf("idx_records_order_check_time not created: %d, %v", index, err)
+	}
+}
+
+func TestMigrateConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "monitor.db")
+
+	// 模拟常驻服务和 once 同时启动
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			database, err := New(path)
+			if err == nil {
+				database.Close()
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("New() #%d error = %v", i, err)
+		}
+	}
+
+	database, err := New(path)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer database.Close()
+
+	migrations, _ := loadMigrations()
+	var applied int
+	if err := database.db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&applied); err != nil {
+		t.Fatalf("count schema_migrations: %v", err)
+	}
+	if applied != len(migrations) {
+		t.Errorf("schema_migrations has %d rows, want %d", applied, len(migrations))
+	}
+}
+
+func TestSchemaTooNew(t *testing.T) {
+	database, path := newTestDatabase(t)
+	if _, err := database.db.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (999, '0999_future', ?)", time.Now().Round(0)); err != nil {
+		t.Fatalf("insert future version: %v", err)
+	}
+	database.Close()
+
+	if err := CheckSchema(path); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("CheckSchema() = %v, want ErrSchemaTooNew", err)
+	}
+	if _, err := New(path); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("New() = %v, want ErrSchemaTooNew", err)
+	}
+}
+
+func TestCheckSchemaMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.db")
+	if err := CheckSchema(path); err != nil {
+		t.Errorf("CheckSchema() on a missing file = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("CheckSchema() created %s", path)
+	}
+}
@@ -0,0 +1,34 @@
+-- 初始表结构。引入迁移之前创建的数据库已有这些表，因此使用 IF NOT EXISTS
+CREATE TABLE IF NOT EXISTS delivery_records (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	order_id TEXT NOT NULL,
+	estimate_time TEXT NOT NULL,
+	lock_order_time DATETIME NOT NULL,
+	check_time DATETIME NOT NULL,
+	is_approaching BOOLEAN NOT NULL DEFAULT 0,
+	approach_message TEXT,
+	time_changed BOOLEAN NOT NULL DEFAULT 0,
+	previous_estimate TEXT,
+	notification_sent BOOLEAN NOT NULL DEFAULT 0,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_id ON delivery_records(order_id);
+CREATE INDEX IF NOT EXISTS idx_check_time ON delivery_records(check_time);
+CREATE INDEX IF NOT EXISTS idx_created_at ON delivery_records(created_at);
+
+CREATE TABLE IF NOT EXISTS check_failures (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	order_id TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	message TEXT,
+	check_time DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_failures_check_time ON check_failures(check_time);
+
+CREATE TABLE IF NOT EXISTS monitor_state (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL,
+	updated_at DATETIME NOT NULL
+);
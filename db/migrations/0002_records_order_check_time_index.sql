@@ -0,0 +1,2 @@
+-- 记录查询均按订单过滤并按检查时间排序，使用联合索引避免排序
+CREATE INDEX idx_records_order_check_time ON delivery_records(order_id, check_time);
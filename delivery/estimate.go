@@ -0,0 +1,102 @@
+package delivery
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	// relativeEstimatePattern 相对时间，例如 "预计6-8周内交付"、"8周内"、"30天内"、"1-2个月"
+	relativeEstimatePattern = regexp.MustCompile(`(\d+)(?:\s*[-~～至到]\s*(\d+))?\s*(周|天|个月)(内)?`)
+
+	// dateEstimatePattern 具体日期，例如 "2025-12-01"、"2025/12/01"、"2025年12月1日"、"12月1日"
+	dateEstimatePattern = regexp.MustCompile(`(\d{4})[-/.年](\d{1,2})[-/.月](\d{1,2})日?|(\d{1,2})月(\d{1,2})日`)
+
+	// monthEstimatePattern 月份或旬，例如 "2025年12月"、"12月下旬"
+	monthEstimatePattern = regexp.MustCompile(`(?:(\d{4})年)?(\d{1,2})月(上旬|中旬|下旬)?`)
+)
+
+// ParseEstimate 将官方预计交付时间文本解析为日期范围
+// 支持相对时间（以 ref 即检查时间为起点）、具体日期、两个日期组成的范围以及月份/旬；
+// 无法识别时 ok 为 false
+func ParseEstimate(estimate string, ref time.Time) (minDate, maxDate time.Time, ok bool) {
+	loc := ref.Location()
+	today := time.Date(ref.Year(), ref.Month(), ref.Day(), 0, 0, 0, 0, loc)
+
+	if m := relativeEstimatePattern.FindStringSubmatch(estimate); m != nil {
+		low, _ := strconv.Atoi(m[1])
+		high := low
+		if m[2] != "" {
+			high, _ = strconv.Atoi(m[2])
+		}
+		if high < low {
+			low, high = high, low
+		}
+		// "8周内" 表示最早即日、最晚 8 周后
+		if m[2] == "" && m[4] != "" {
+			low = 0
+		}
+		return addUnit(today, low, m[3]), addUnit(today, high, m[3]), true
+	}
+
+	if matches := dateEstimatePattern.FindAllStringSubmatch(estimate, 2); len(matches) > 0 {
+		var dates []time.Time
+		for _, m := range matches {
+			if m[1] != "" {
+				dates = append(dates, makeDate(m[1], m[2], m[3], today))
+			} else {
+				dates = append(dates, makeDate("", m[4], m[5], today))
+			}
+		}
+		minDate, maxDate = dates[0], dates[len(dates)-1]
+		if maxDate.Before(minDate) {
+			minDate, maxDate = maxDate, minDate
+		}
+		return minDate, maxDate, true
+	}
+
+	if m := monthEstimatePattern.FindStringSubmatch(estimate); m != nil {
+		start := makeDate(m[1], m[2], "1", today)
+		end := start.AddDate(0, 1, -1)
+		switch m[3] {
+		case "上旬":
+			end = start.AddDate(0, 0, 9)
+		case "中旬":
+			start, end = start.AddDate(0, 0, 10), start.AddDate(0, 0, 19)
+		case "下旬":
+			start = start.AddDate(0, 0, 20)
+		}
+		return start, end, true
+	}
+
+	return time.Time{}, time.Time{}, false
+}
+
+// addUnit 按单位增加时间
+func addUnit(t time.Time, n int, unit string) time.Time {
+	switch unit {
+	case "周":
+		return t.AddDate(0, 0, n*7)
+	case "个月":
+		return t.AddDate(0, n, 0)
+	default:
+		return t.AddDate(0, 0, n)
+	}
+}
+
+// makeDate 构造日期，未给出年份时取 today 之后最近的一次（半年以前的月份视为明年）
+func makeDate(year, month, day string, today time.Time) time.Time {
+	m, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(day)
+	if year != "" {
+		y, _ := strconv.Atoi(year)
+		return time.Date(y, time.Month(m), d, 0, 0, 0, 0, today.Location())
+	}
+
+	date := time.Date(today.Year(), time.Month(m), d, 0, 0, 0, 0, today.Location())
+	if date.Before(today.AddDate(0, -6, 0)) {
+		date = date.AddDate(1, 0, 0)
+	}
+	return date
+}
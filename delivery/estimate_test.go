@@ -0,0 +1,68 @@
+package delivery
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseEstimate(t *testing.T) {
+	ref := time.Date(2025, 10, 15, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		estimate string
+		min, max string // 期望的日期范围，均为空表示无法解析
+	}{
+		// 相对时间，以检查当天为起点
+		{"预计6-8周内交付", "2025-11-26", "2025-12-10"},
+		{"6 ~ 8 周", "2025-11-26", "2025-12-10"},
+		{"8-6周", "2025-11-26", "2025-12-10"},
+		{"8周内", "2025-10-15", "2025-12-10"},
+		{"30天内", "2025-10-15", "2025-11-14"},
+		{"1至2个月", "2025-11-15", "2025-12-15"},
+
+		// 具体日期和日期范围
+		{"2025-12-01", "2025-12-01", "2025-12-01"},
+		{"预计 2025/12/01 - 2025/12/15 交付", "2025-12-01", "2025-12-15"},
+		{"2025年12月1日至2025年11月20日", "2025-11-20", "2025-12-01"},
+		{"12月1日", "2025-12-01", "2025-12-01"},
+		{"5月5日", "2025-05-05", "2025-05-05"},
+		{"3月5日", "2026-03-05", "2026-03-05"}, // 半年以前的月份视为明年
+
+		// 月份和旬
+		{"2025年12月", "2025-12-01", "2025-12-31"},
+		{"12月上旬", "2025-12-01", "2025-12-10"},
+		{"12月中旬", "2025-12-11", "2025-12-20"},
+		{"12月下旬", "2025-12-21", "2025-12-31"},
+		{"2月下旬", "2026-02-21", "2026-02-28"},
+
+		// 无法识别
+		{"待定", "", ""},
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.estimate, func(t *testing.T) {
+			minDate, maxDate, ok := ParseEstimate(tt.estimate, ref)
+			if tt.min == "" {
+				if ok {
+					t.Fatalf("ParseEstimate(%q) = %s ~ %s, want not ok", tt.estimate, minDate, maxDate)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("ParseEstimate(%q) not ok", tt.estimate)
+			}
+
+			const layout = "2006-01-02"
+			if got := minDate.Format(layout); got != tt.min {
+				t.Errorf("min = %s, want %s", got, tt.min)
+			}
+			if got := maxDate.Format(layout); got != tt.max {
+				t.Errorf("max = %s, want %s", got, tt.max)
+			}
+			if minDate.Location() != ref.Location() || minDate.Hour() != 0 || maxDate.Hour() != 0 {
+				t.Errorf("dates not at midnight in ref location: %v ~ %v", minDate, maxDate)
+			}
+		})
+	}
+}
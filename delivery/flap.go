@@ -0,0 +1,168 @@
+package delivery
+
+import (
+	"time"
+)
+
+// FlapDetector 预计交付时间波动检测器
+// 当官方预计时间在若干值之间来回跳动（A→B→A）时暂缓变更通知，
+// 直到新值连续稳定 StableChecks 次检查或持续 StableDuration 后再确认。
+type FlapDetector struct {
+	StableChecks   int           // 连续稳定检查次数，0 表示不按次数确认
+	StableDuration time.Duration // 稳定持续时长，0 表示不按时长确认
+	Window         time.Duration // 回溯历史变更的时间窗口
+
+	confirmed     string    // 最近一次已通知（确认）的预计时间
+	pending       string    // 等待确认的预计时间
+	pendingSince  time.Time // 等待确认的预计时间首次出现的时间
+	pendingChecks int       // 等待确认的预计时间连续出现的次数
+	seen          []string  // 波动期间出现过的预计时间
+}
+
+// NewFlapDetector 创建波动检测器
+func NewFlapDetector(stableChecks int, stableDuration, window time.Duration) *FlapDetector {
+	return &FlapDetector{
+		StableChecks:   stableChecks,
+		StableDuration: stableDuration,
+		Window:         window,
+	}
+}
+
+// Configure 更新检测参数，检测被关闭时结束暂缓状态并返回 true
+// 暂缓中的值已记为最新预计时间，关闭后不再等待确认，直接记为已确认
+func (f *FlapDetector) Configure(stableChecks int, stableDuration, window time.Duration) bool {
+	f.StableChecks = stableChecks
+	f.StableDuration = stableDuration
+	f.Window = window
+	return f.releaseIfDisabled()
+}
+
+// releaseIfDisabled 检测关闭时结束暂缓状态
+func (f *FlapDetector) releaseIfDisabled() bool {
+	if f.Enabled() || !f.Holding() {
+		return false
+	}
+	f.Release()
+	return true
+}
+
+// Enabled 是否启用波动检测
+func (f *FlapDetector) Enabled() bool {
+	return f.StableChecks > 0 || f.StableDuration > 0
+}
+
+// Holding 是否处于暂缓通知状态
+func (f *FlapDetector) Holding() bool {
+	return f.pending != ""
+}
+
+// Confirmed 返回最近一次已确认的预计时间
+func (f *FlapDetector) Confirmed() string {
+	return f.confirmed
+}
+
+// SetConfirmed 记录已通知（确认）的预计时间
+func (f *FlapDetector) SetConfirmed(estimate string) {
+	f.confirmed = estimate
+}
+
+// IsOscillating 判断新的预计时间是否为回跳
+// recentEstimates 为 Window 内历史变更记录中出现过的预计时间（包括变更前后的值）
+func (f *FlapDetector) IsOscillating(current, last string, recentEstimates []string) bool {
+	if current == last {
+		return false
+	}
+	for _, estimate := range recentEstimates {
+		if estimate != "" && estimate != last && estimate == current {
+			return true
+		}
+	}
+	return false
+}
+
+// Hold 开始暂缓通知
+func (f *FlapDetector) Hold(current, last string, now time.Time) {
+	f.pending = current
+	f.pendingSince = now
+	f.pendingChecks = 1
+	f.seen = appendUnique(f.seen, last)
+	f.seen = appendUnique(f.seen, current)
+}
+
+// Observe 在暂缓状态下记录一次检查结果
+// 当新值稳定时返回 true，调用方应发送确认通知并调用 Release
+func (f *FlapDetector) Observe(current string, now time.Time) bool {
+	if current != f.pending {
+		f.pending = current
+		f.pendingSince = now
+		f.pendingChecks = 0
+		f.seen = appendUnique(f.seen, current)
+	}
+	f.pendingChecks++
+
+	if f.StableChecks > 0 && f.pendingChecks >= f.StableChecks {
+		return true
+	}
+	if f.StableDuration > 0 && now.Sub(f.pendingSince) >= f.StableDuration {
+		return true
+	}
+	return false
+}
+
+// PendingInfo 返回等待确认的预计时间、稳定次数、稳定时长和波动期间出现过的值
+func (f *FlapDetector) PendingInfo(now time.Time) (string, int, time.Duration, []string) {
+	seen := make([]string, len(f.seen))
+	copy(seen, f.seen)
+	return f.pending, f.pendingChecks, now.Sub(f.pendingSince), seen
+}
+
+// Release 结束暂缓状态并将稳定值记为已确认
+func (f *FlapDetector) Release() {
+	f.confirmed = f.pending
+	f.pending = ""
+	f.pendingSince = time.Time{}
+	f.pendingChecks = 0
+	f.seen = nil
+}
+
+// FlapState 波动检测器的可持久化状态，用于单次检查模式跨进程保留暂缓状态
+type FlapState struct {
+	Confirmed     string    `json:"confirmed"`
+	Pending       string    `json:"pending,omitempty"`
+	PendingSince  time.Time `json:"pending_since"`
+	PendingChecks int       `json:"pending_checks,omitempty"`
+	Seen          []string  `json:"seen,omitempty"`
+}
+
+// State 导出当前状态
+func (f *FlapDetector) State() FlapState {
+	seen := make([]string, len(f.seen))
+	copy(seen, f.seen)
+	return FlapState{
+		Confirmed:     f.confirmed,
+		Pending:       f.pending,
+		PendingSince:  f.pendingSince,
+		PendingChecks: f.pendingChecks,
+		Seen:          seen,
+	}
+}
+
+// Restore 恢复之前导出的状态，检测已关闭时不恢复暂缓状态
+func (f *FlapDetector) Restore(state FlapState) {
+	f.confirmed = state.Confirmed
+	f.pending = state.Pending
+	f.pendingSince = state.PendingSince
+	f.pendingChecks = state.PendingChecks
+	f.seen = append([]string(nil), state.Seen...)
+	f.releaseIfDisabled()
+}
+
+// appendUnique 追加不重复的值
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,151 @@
+package delivery
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFlapDetectorEnabled(t *testing.T) {
+	tests := []struct {
+		checks   int
+		duration time.Duration
+		want     bool
+	}{
+		{0, 0, false},
+		{3, 0, true},
+		{0, time.Hour, true},
+		{3, time.Hour, true},
+	}
+	for _, tt := range tests {
+		if got := NewFlapDetector(tt.checks, tt.duration, 0).Enabled(); got != tt.want {
+			t.Errorf("Enabled() with checks=%d duration=%s = %v, want %v", tt.checks, tt.duration, got, tt.want)
+		}
+	}
+}
+
+func TestFlapDetectorIsOscillating(t *testing.T) {
+	f := NewFlapDetector(2, 0, 0)
+	tests := []struct {
+		name    string
+		current string
+		last    string
+		recent  []string
+		want    bool
+	}{
+		{"back to a recent value", "A", "B", []string{"A", "B"}, true},
+		{"new value", "C", "B", []string{"A", "B"}, false},
+		{"unchanged", "B", "B", []string{"A", "B"}, false},
+		{"no history", "A", "B", nil, false},
+		{"empty previous estimate ignored", "", "B", []string{"", "B"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := f.IsOscillating(tt.current, tt.last, tt.recent); got != tt.want {
+				t.Errorf("IsOscillating(%q, %q, %v) = %v, want %v", tt.current, tt.last, tt.recent, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlapDetectorStableChecks(t *testing.T) {
+	now := time.Date(2025, 10, 15, 10, 0, 0, 0, time.UTC)
+	f := NewFlapDetector(3, 0, 0)
+	f.SetConfirmed("B")
+
+	// B → A 回跳，暂缓；A 需再连续出现 2 次（共 3 次）才确认
+	f.Hold("A", "B", now)
+	if !f.Holding() {
+		t.Fatal("not holding after Hold")
+	}
+	if f.Observe("A", now.Add(time.Minute)) {
+		t.Fatal("confirmed after 2 checks, want 3")
+	}
+
+	// 中途跳到新值时重新计数
+	if f.Observe("C", now.Add(2*time.Minute)) {
+		t.Fatal("confirmed a new value on its first check")
+	}
+	if f.Observe("C", now.Add(3*time.Minute)) {
+		t.Fatal("confirmed after 2 checks of the new value")
+	}
+	if !f.Observe("C", now.Add(4*time.Minute)) {
+		t.Fatal("not confirmed after 3 checks of the new value")
+	}
+
+	pending, checks, stableFor, seen := f.PendingInfo(now.Add(4 * time.Minute))
+	if pending != "C" || checks != 3 || stableFor != 2*time.Minute {
+		t.Errorf("PendingInfo() = %q, %d, %s; want C, 3, 2m0s", pending, checks, stableFor)
+	}
+	if want := []string{"B", "A", "C"}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("seen = %v, want %v", seen, want)
+	}
+
+	f.Release()
+	if f.Holding() || f.Confirmed() != "C" {
+		t.Errorf("after Release: holding=%v confirmed=%q, want false C", f.Holding(), f.Confirmed())
+	}
+	if _, _, _, seen := f.PendingInfo(now); len(seen) != 0 {
+		t.Errorf("seen not cleared after Release: %v", seen)
+	}
+}
+
+func TestFlapDetectorStableDuration(t *testing.T) {
+	now := time.Date(2025, 10, 15, 10, 0, 0, 0, time.UTC)
+	f := NewFlapDetector(0, time.Hour, 0)
+
+	f.Hold("A", "B", now)
+	if f.Observe("A", now.Add(59*time.Minute)) {
+		t.Fatal("confirmed before StableDuration")
+	}
+	if !f.Observe("A", now.Add(time.Hour)) {
+		t.Fatal("not confirmed after StableDuration")
+	}
+}
+
+func TestFlapDetectorDisabledWhileHolding(t *testing.T) {
+	now := time.Date(2025, 10, 15, 10, 0, 0, 0, time.UTC)
+	f := NewFlapDetector(3, 0, 0)
+	f.SetConfirmed("B")
+	f.Hold("A", "B", now)
+
+	// 热加载保持开启时不影响暂缓状态
+	if f.Configure(5, 0, time.Hour) || !f.Holding() {
+		t.Fatal("holding state dropped while detection stays enabled")
+	}
+
+	// 两项都改为 0 时结束暂缓，暂缓中的值记为已确认
+	if !f.Configure(0, 0, 0) {
+		t.Fatal("Configure(0, 0, 0) did not report releasing the held estimate")
+	}
+	if f.Holding() || f.Confirmed() != "A" {
+		t.Errorf("after disabling: holding=%v confirmed=%q, want released with A confirmed", f.Holding(), f.Confirmed())
+	}
+
+	// 单次检查模式下恢复的暂缓状态同样被结束
+	held := NewFlapDetector(3, 0, 0)
+	held.SetConfirmed("B")
+	held.Hold("A", "B", now)
+	disabled := NewFlapDetector(0, 0, 0)
+	disabled.Restore(held.State())
+	if disabled.Holding() || disabled.Confirmed() != "A" {
+		t.Errorf("restored into a disabled detector: holding=%v confirmed=%q", disabled.Holding(), disabled.Confirmed())
+	}
+}
+
+func TestFlapDetectorStateRoundTrip(t *testing.T) {
+	now := time.Date(2025, 10, 15, 10, 0, 0, 0, time.UTC)
+	f := NewFlapDetector(2, 0, 0)
+	f.SetConfirmed("B")
+	f.Hold("A", "B", now)
+
+	restored := NewFlapDetector(2, 0, 0)
+	restored.Restore(f.State())
+
+	if !restored.Holding() || restored.Confirmed() != "B" {
+		t.Fatalf("restored holding=%v confirmed=%q, want true B", restored.Holding(), restored.Confirmed())
+	}
+	if !restored.Observe("A", now.Add(time.Minute)) {
+		t.Error("restored detector lost the pending check count")
+	}
+}
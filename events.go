@@ -0,0 +1,47 @@
+package main
+
+import (
+	"lixiang-monitor/notifier"
+	"lixiang-monitor/web"
+)
+
+// publishEvent 向打开仪表板的浏览器推送实时事件，未启用 Web 服务器时忽略
+func (m *Monitor) publishEvent(eventType string, data interface{}) {
+	if m.webServer != nil {
+		m.webServer.Publish(eventType, data)
+	}
+}
+
+// publishCookieStatus Cookie 状态与上次推送的不同时推送 cookie 事件
+func (m *Monitor) publishCookieStatus() {
+	status := m.cookieManager.GetStatus()
+
+	m.mu.Lock()
+	changed := status != m.lastCookieStatus
+	m.lastCookieStatus = status
+	m.mu.Unlock()
+
+	if changed {
+		m.publishEvent(web.EventCookie, map[string]interface{}{
+			"status":   status,
+			"failures": m.cookieManager.Snapshot().ConsecutiveFailure,
+		})
+	}
+}
+
+// onNotificationSent 每个通知器发送完成后记录指标并推送 notification 事件
+func (m *Monitor) onNotificationSent(n notifier.Notifier, title string, err error) {
+	m.metrics.observeNotification(n, err)
+
+	data := map[string]interface{}{
+		"notifier": notifier.Name(n),
+		"title":    title,
+		"success":  err == nil,
+	}
+	if err != nil {
+		// 通知器的错误信息中含有 Webhook 地址、SendKey 或设备 Key，而 /api/events 在未启用认证时对外开放，
+		// 因此不推送原始错误，完整错误只写入服务日志
+		data["error"] = "发送失败，详见服务日志"
+	}
+	m.publishEvent(web.EventNotification, data)
+}
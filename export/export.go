@@ -0,0 +1,145 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // 内置时区数据，系统缺少 zoneinfo 时 tz 参数仍可用
+
+	"lixiang-monitor/db"
+)
+
+// Format 导出格式
+type Format string
+
+// 支持的导出格式
+const (
+	FormatJSON   Format = "json"   // 带缩进的 JSON 数组
+	FormatNDJSON Format = "ndjson" // JSON Lines，每行一条记录
+	FormatCSV    Format = "csv"
+	FormatXLSX   Format = "xlsx" // Excel 工作簿
+)
+
+// Formats 所有支持的导出格式
+var Formats = []Format{FormatJSON, FormatNDJSON, FormatCSV, FormatXLSX}
+
+// columns 表格格式（CSV、Excel）的列名
+var columns = []string{
+	"id", "order_id", "estimate_time", "lock_order_time", "check_time",
+	"is_approaching", "approach_message", "time_changed", "previous_estimate", "notification_sent",
+}
+
+// ParseFormat 解析导出格式，jsonl 视为 ndjson
+func ParseFormat(s string) (Format, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "jsonl" {
+		return FormatNDJSON, nil
+	}
+	for _, format := range Formats {
+		if string(format) == s {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("不支持的导出格式: %s（可选 json、ndjson、csv、xlsx）", s)
+}
+
+// ContentType 返回导出格式对应的 Content-Type
+func (f Format) ContentType() string {
+	switch f {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "application/json"
+	}
+}
+
+// Filename 返回导出文件名，例如 lixiang-monitor-123-20251020.csv
+func (f Format) Filename(orderID string, now time.Time) string {
+	extension := string(f)
+	if f == FormatNDJSON {
+		extension = "jsonl"
+	}
+	return fmt.Sprintf("lixiang-monitor-%s-%s.%s", orderID, now.Format("20060102"), extension)
+}
+
+// Write 按指定格式写出检查记录，时间统一转换到 loc 时区（为 nil 时使用本地时区）
+// CSV 和 JSON 中的时间带有时区偏移，Excel 中为 loc 时区的日期时间单元格
+func Write(w io.Writer, format Format, records []*db.DeliveryRecord, loc *time.Location) error {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		converted := make([]*db.DeliveryRecord, 0, len(records))
+		for _, record := range records {
+			converted = append(converted, inLocation(record, loc))
+		}
+		return encoder.Encode(converted)
+	case FormatNDJSON:
+		encoder := json.NewEncoder(w)
+		for _, record := range records {
+			if err := encoder.Encode(inLocation(record, loc)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatCSV:
+		return writeCSV(w, records, loc)
+	case FormatXLSX:
+		return writeXLSX(w, records, loc)
+	default:
+		return fmt.Errorf("不支持的导出格式: %s", format)
+	}
+}
+
+// inLocation 返回时间转换到 loc 时区后的记录副本，零值保持不变
+func inLocation(record *db.DeliveryRecord, loc *time.Location) *db.DeliveryRecord {
+	converted := *record
+	for _, t := range []*time.Time{&converted.LockOrderTime, &converted.CheckTime, &converted.CreatedAt} {
+		if !t.IsZero() {
+			*t = t.In(loc)
+		}
+	}
+	return &converted
+}
+
+// writeCSV 以 CSV 格式写出检查记录，时间为 RFC3339 格式
+func writeCSV(w io.Writer, records []*db.DeliveryRecord, loc *time.Location) error {
+	writer := csv.NewWriter(w)
+	writer.Write(columns)
+	for _, record := range records {
+		writer.Write([]string{
+			strconv.Itoa(record.ID),
+			record.OrderID,
+			record.EstimateTime,
+			formatTime(record.LockOrderTime, loc),
+			formatTime(record.CheckTime, loc),
+			strconv.FormatBool(record.IsApproaching),
+			record.ApproachMessage,
+			strconv.FormatBool(record.TimeChanged),
+			record.PreviousEstimate,
+			strconv.FormatBool(record.NotificationSent),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatTime 格式化为带时区偏移的时间，零值输出为空
+func formatTime(t time.Time, loc *time.Location) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(loc).Format(time.RFC3339)
+}
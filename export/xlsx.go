@@ -0,0 +1,196 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"lixiang-monitor/db"
+)
+
+// Excel 工作簿的固定部分，只包含一个工作表，字符串使用内联字符串而非共享字符串表
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`
+
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="检查记录" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`
+
+	// 样式 0 为默认，1 为表头加粗，2 为日期时间
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs><cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles></styleSheet>`
+
+	// 冻结表头，并设置各列宽度
+	xlsxSheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews><cols><col min="1" max="2" width="12" customWidth="1"/><col min="3" max="3" width="24" customWidth="1"/><col min="4" max="5" width="20" customWidth="1"/><col min="6" max="6" width="14" customWidth="1"/><col min="7" max="7" width="40" customWidth="1"/><col min="8" max="8" width="14" customWidth="1"/><col min="9" max="9" width="24" customWidth="1"/><col min="10" max="10" width="18" customWidth="1"/></cols><sheetData>`
+
+	xlsxSheetFooter = `</sheetData></worksheet>`
+)
+
+// Excel 单元格样式索引，对应 xlsxStyles 中的 cellXfs
+const (
+	styleHeader   = 1
+	styleDateTime = 2
+)
+
+// excelEpoch Excel 日期序列号的起点
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// writeXLSX 以 Excel 工作簿格式写出检查记录，时间为 loc 时区的日期时间单元格
+func writeXLSX(w io.Writer, records []*db.DeliveryRecord, loc *time.Location) error {
+	archive := zip.NewWriter(w)
+	modified := time.Now()
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, part := range parts {
+		f, err := createPart(archive, part.name, modified)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+
+	f, err := createPart(archive, "xl/worksheets/sheet1.xml", modified)
+	if err != nil {
+		return err
+	}
+	sheet := &sheetWriter{w: bufio.NewWriter(f)}
+	sheet.raw(xlsxSheetHeader)
+
+	sheet.startRow()
+	for _, column := range columns {
+		sheet.stringCell(column, styleHeader)
+	}
+	sheet.endRow()
+
+	for _, record := range records {
+		sheet.startRow()
+		sheet.numberCell(strconv.Itoa(record.ID), 0)
+		sheet.stringCell(record.OrderID, 0)
+		sheet.stringCell(record.EstimateTime, 0)
+		sheet.timeCell(record.LockOrderTime, loc)
+		sheet.timeCell(record.CheckTime, loc)
+		sheet.boolCell(record.IsApproaching)
+		sheet.stringCell(record.ApproachMessage, 0)
+		sheet.boolCell(record.TimeChanged)
+		sheet.stringCell(record.PreviousEstimate, 0)
+		sheet.boolCell(record.NotificationSent)
+		sheet.endRow()
+	}
+
+	sheet.raw(xlsxSheetFooter)
+	if sheet.err != nil {
+		return sheet.err
+	}
+	if err := sheet.w.Flush(); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// createPart 在工作簿中创建一个压缩的文件
+func createPart(archive *zip.Writer, name string, modified time.Time) (io.Writer, error) {
+	return archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+}
+
+// sheetWriter 逐行写出工作表 XML，记录第一个写入错误
+type sheetWriter struct {
+	w   *bufio.Writer
+	row int
+	col int
+	err error
+}
+
+// raw 写出原始 XML
+func (s *sheetWriter) raw(text string) {
+	if s.err == nil {
+		_, s.err = s.w.WriteString(text)
+	}
+}
+
+// startRow 开始新的一行
+func (s *sheetWriter) startRow() {
+	s.row++
+	s.col = 0
+	s.raw(fmt.Sprintf(`<row r="%d">`, s.row))
+}
+
+// endRow 结束当前行
+func (s *sheetWriter) endRow() {
+	s.raw(`</row>`)
+}
+
+// cell 写出单元格开始标签，type 为空时为数字单元格
+func (s *sheetWriter) cell(cellType string, style int) {
+	ref := string(rune('A'+s.col)) + strconv.Itoa(s.row)
+	s.col++
+
+	tag := `<c r="` + ref + `"`
+	if cellType != "" {
+		tag += ` t="` + cellType + `"`
+	}
+	if style != 0 {
+		tag += ` s="` + strconv.Itoa(style) + `"`
+	}
+	s.raw(tag + `>`)
+}
+
+// stringCell 写出内联字符串单元格，空字符串写出空单元格
+func (s *sheetWriter) stringCell(value string, style int) {
+	if value == "" {
+		s.col++
+		return
+	}
+	s.cell("inlineStr", style)
+	s.raw(`<is><t xml:space="preserve">`)
+	if s.err == nil {
+		s.err = xml.EscapeText(s.w, []byte(value))
+	}
+	s.raw(`</t></is></c>`)
+}
+
+// numberCell 写出数字单元格
+func (s *sheetWriter) numberCell(value string, style int) {
+	s.cell("", style)
+	s.raw(`<v>` + value + `</v></c>`)
+}
+
+// boolCell 写出布尔单元格
+func (s *sheetWriter) boolCell(value bool) {
+	s.cell("b", 0)
+	if value {
+		s.raw(`<v>1</v></c>`)
+	} else {
+		s.raw(`<v>0</v></c>`)
+	}
+}
+
+// timeCell 写出日期时间单元格，Excel 没有时区概念，写入 loc 时区的本地时间；零值写出空单元格
+func (s *sheetWriter) timeCell(t time.Time, loc *time.Location) {
+	if t.IsZero() {
+		s.col++
+		return
+	}
+	local := t.In(loc)
+	wall := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, time.UTC)
+	serial := wall.Sub(excelEpoch).Seconds() / 86400
+	s.numberCell(strconv.FormatFloat(serial, 'f', -1, 64), styleDateTime)
+}
@@ -0,0 +1,136 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"lixiang-monitor/db"
+)
+
+// xlsxSheet 测试用的工作表结构，只解析单元格
+type xlsxSheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Style  int    `xml:"s,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func TestWriteXLSX(t *testing.T) {
+	shanghai := time.FixedZone("UTC+8", 8*3600)
+	checkTime := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC) // 上海时间 2025-10-01 08:00
+	records := []*db.DeliveryRecord{
+		{
+			ID:            7,
+			OrderID:       "order-1",
+			EstimateTime:  `预计 <6-8> 周 & "交付"`,
+			CheckTime:     checkTime,
+			IsApproaching: true,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatXLSX, records, shanghai); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("not a zip archive: %v", err)
+	}
+	parts := make(map[string][]byte)
+	for _, f := range archive.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", f.Name, err)
+		}
+		parts[f.Name] = data
+	}
+	for _, name := range []string{
+		"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml",
+		"xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml",
+	} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("missing part %s", name)
+		} else if err := xml.Unmarshal(parts[name], new(struct{})); err != nil {
+			t.Errorf("%s is not valid XML: %v", name, err)
+		}
+	}
+
+	var sheet xlsxSheet
+	if err := xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet); err != nil {
+		t.Fatalf("parse sheet: %v", err)
+	}
+	if len(sheet.Rows) != 2 {
+		t.Fatalf("got %d rows, want header and 1 record", len(sheet.Rows))
+	}
+
+	header := sheet.Rows[0]
+	if header.R != 1 || len(header.Cells) != len(columns) {
+		t.Fatalf("header row r=%d with %d cells, want r=1 with %d", header.R, len(header.Cells), len(columns))
+	}
+	for i, c := range header.Cells {
+		if c.Inline != columns[i] || c.Style != styleHeader {
+			t.Errorf("header cell %s = %q style %d, want %q style %d", c.Ref, c.Inline, c.Style, columns[i], styleHeader)
+		}
+	}
+
+	// 零值时间和空字符串不写出单元格，单元格引用需跳过对应列
+	type cell struct{ typ, value string }
+	got := make(map[string]cell)
+	for _, c := range sheet.Rows[1].Cells {
+		value := c.Value
+		if c.Type == "inlineStr" {
+			value = c.Inline
+		}
+		got[c.Ref] = cell{c.Type, value}
+	}
+	want := map[string]cell{
+		"A2": {"", "7"},
+		"B2": {"inlineStr", "order-1"},
+		"C2": {"inlineStr", `预计 <6-8> 周 & "交付"`},
+		"F2": {"b", "1"},
+		"H2": {"b", "0"},
+		"J2": {"b", "0"},
+	}
+	for ref, w := range want {
+		if got[ref] != w {
+			t.Errorf("cell %s = %+v, want %+v", ref, got[ref], w)
+		}
+	}
+	for _, ref := range []string{"D2", "G2", "I2"} {
+		if _, ok := got[ref]; ok {
+			t.Errorf("cell %s written for an empty value", ref)
+		}
+	}
+
+	// 2025-10-01 为 Excel 序列号 45931，08:00 为 1/3 天
+	serial, err := strconv.ParseFloat(got["E2"].value, 64)
+	if err != nil {
+		t.Fatalf("check_time cell %q: %v", got["E2"].value, err)
+	}
+	if want := 45931 + 1.0/3; math.Abs(serial-want) > 1e-9 {
+		t.Errorf("check_time serial = %v, want %v", serial, want)
+	}
+	for _, c := range sheet.Rows[1].Cells {
+		if c.Ref == "E2" && c.Style != styleDateTime {
+			t.Errorf("check_time style = %d, want %d", c.Style, styleDateTime)
+		}
+	}
+}
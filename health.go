@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"lixiang-monitor/utils"
+	"lixiang-monitor/web"
+)
+
+// Readiness 实现 web.HealthChecker 接口
+// 检查数据库可读、订单检查任务已调度、最近一次成功检查在允许的间隔内、Cookie 未失效
+func (m *Monitor) Readiness(ctx context.Context) []web.HealthCheck {
+	return []web.HealthCheck{
+		m.checkDatabaseReady(ctx),
+		m.checkSchedulerReady(),
+		m.checkLastCheckReady(),
+		m.checkCookieReady(),
+	}
+}
+
+// checkDatabaseReady 检查数据库是否可读
+func (m *Monitor) checkDatabaseReady(ctx context.Context) web.HealthCheck {
+	check := web.HealthCheck{Name: "database"}
+	if m.database == nil {
+		check.Detail = "数据库未初始化"
+		return check
+	}
+	if err := m.database.Ping(ctx); err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+	check.OK = true
+	return check
+}
+
+// checkSchedulerReady 检查订单检查任务是否已调度
+func (m *Monitor) checkSchedulerReady() web.HealthCheck {
+	check := web.HealthCheck{Name: "scheduler"}
+	next, _, ok := m.checkSchedule()
+	if !ok {
+		check.Detail = "订单检查任务未调度"
+		return check
+	}
+	check.OK = true
+	check.Detail = "下次检查: " + next.Format(utils.DateTimeFormat)
+	return check
+}
+
+// checkLastCheckReady 检查最近一次成功检查是否在 HealthMaxMissedChecks 个检查间隔内
+// 启动后尚未成功检查时，从启动时间开始计算
+func (m *Monitor) checkLastCheckReady() web.HealthCheck {
+	check := web.HealthCheck{Name: "last_check"}
+
+	m.mu.RLock()
+	lastSuccess := m.lastSuccessfulCheck
+	startedAt := m.startedAt
+	maxMissed := m.HealthMaxMissedChecks
+	m.mu.RUnlock()
+
+	_, interval, ok := m.checkSchedule()
+	if !ok {
+		check.Detail = "订单检查任务未调度"
+		return check
+	}
+	allowed := time.Duration(maxMissed) * interval
+
+	if lastSuccess.IsZero() {
+		if time.Since(startedAt) > allowed {
+			check.Detail = fmt.Sprintf("启动后 %s 内没有成功的检查", allowed)
+			return check
+		}
+		check.OK = true
+		check.Detail = "等待首次成功检查"
+		return check
+	}
+
+	check.Detail = "最近成功检查: " + lastSuccess.Format(utils.DateTimeFormat)
+	if time.Since(lastSuccess) > allowed {
+		check.Detail += fmt.Sprintf("，已超过 %d 个检查间隔 (%s)", maxMissed, allowed)
+		return check
+	}
+	check.OK = true
+	return check
+}
+
+// checkCookieReady 检查 Cookie 是否失效或已过期
+func (m *Monitor) checkCookieReady() web.HealthCheck {
+	check := web.HealthCheck{Name: "cookie"}
+
+	if failures := m.cookieManager.Snapshot().ConsecutiveFailure; failures > 0 {
+		check.Detail = fmt.Sprintf("最近 %d 次请求认证失败", failures)
+		return check
+	}
+
+	expiry, source := m.cookieManager.ExpiryTime()
+	if expiry.IsZero() {
+		check.OK = true
+		check.Detail = "过期时间未知"
+		return check
+	}
+
+	check.Detail = fmt.Sprintf("过期时间: %s（依据 %s）", expiry.Format(utils.DateTimeFormat), source)
+	if time.Now().After(expiry) {
+		check.Detail = "已过期，" + check.Detail
+		return check
+	}
+	check.OK = true
+	return check
+}
+
+// checkSchedule 返回订单检查任务的下次执行时间和检查间隔，任务未调度时 ok 为 false
+func (m *Monitor) checkSchedule() (next time.Time, interval time.Duration, ok bool) {
+	m.mu.RLock()
+	entryID := m.checkEntryID
+	m.mu.RUnlock()
+
+	if entryID == 0 {
+		return time.Time{}, 0, false
+	}
+	entry := m.cron.Entry(entryID)
+	if !entry.Valid() || entry.Next.IsZero() {
+		return time.Time{}, 0, false
+	}
+	return entry.Next, entry.Schedule.Next(entry.Next).Sub(entry.Next), true
+}
@@ -1,13 +1,21 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"lixiang-monitor/cfg"
+	"lixiang-monitor/chat"
 	"lixiang-monitor/cookie"
 	"lixiang-monitor/db"
 	"lixiang-monitor/delivery"
@@ -19,6 +27,9 @@ import (
 	"github.com/robfig/cron/v3"
 )
 
+// shutdownTimeout 优雅退出时等待正在进行的检查和请求完成的最长时间
+const shutdownTimeout = 30 * time.Second
+
 type Monitor struct {
 	OrderID          string
 	LastEstimateTime string
@@ -30,35 +41,99 @@ type Monitor struct {
 	EstimateWeeksMin int       // 预计交付周数范围（最小）
 	EstimateWeeksMax int       // 预计交付周数范围（最大）
 	cron             *cron.Cron
+	configSnapshot   map[string]string // 上次加载的配置快照，用于在配置更新通知中列出变更项
+
+	// 健康检查相关字段
+	HealthMaxMissedChecks int          // 最近一次成功检查最多允许落后的检查间隔数
+	startedAt             time.Time    // 服务启动时间
+	checkEntryID          cron.EntryID // 订单检查定时任务 ID
+	lastSuccessfulCheck   time.Time    // 最近一次成功检查的时间
+	lastCookieStatus      string       // 上次推送给仪表板的 Cookie 状态
 
 	// 定期通知相关字段
 	NotificationInterval        time.Duration // 通知间隔（当交付时间未更新时）
 	EnablePeriodicNotify        bool          // 是否启用定期通知
 	AlwaysNotifyWhenApproaching bool          // 临近交付时总是通知
 
+	// 摘要报告相关字段
+	DigestSchedule string       // 摘要报告 cron 表达式，为空表示不发送
+	lastDigestTime time.Time    // 上次发送摘要报告的时间
+	digestEntryID  cron.EntryID // 摘要报告定时任务 ID，0 表示未调度
+	digestSpec     string       // 已调度的摘要报告 cron 表达式
+
+	// 通知去重与限流
+	NotificationDedupWindow time.Duration // 相同通知的去重窗口
+	NotificationRateBurst   int           // 令牌桶容量（每个通知器/事件类型）
+	NotificationRatePerHour float64       // 每小时补充的令牌数
+
+	// 预计时间波动检测
+	FlapStableChecks   int           // 新值需连续稳定的检查次数
+	FlapStableDuration time.Duration // 新值需稳定的持续时长
+	FlapWindow         time.Duration // 回溯历史变更的时间窗口
+
 	// Cookie 管理相关
 	LastCookieCheckTime      time.Time // 上次 Cookie 检查时间
 	CookieExpiredNotified    bool      // 是否已通知 Cookie 失效
 	ConsecutiveCookieFailure int       // 连续 Cookie 失效次数
 	CookieUpdatedAt          time.Time // Cookie 更新时间
 	CookieValidDays          int       // Cookie 有效天数
+	CookieExpiresAt          time.Time // 从 Cookie 属性中得到的过期时间
+	CookieStateFile          string    // 服务端刷新后的 Cookie 状态文件
+	CookiePersistToConfig    bool      // 是否将刷新后的 Cookie 写回 config.yaml
+	KeepaliveSchedule        string    // 会话保活 cron 表达式，为空表示不启用
+	KeepaliveURL             string    // 会话保活请求的接口地址，为空时使用订单详情接口
 	CookieExpirationWarned   bool      // 是否已发送过期预警
 
+	// 请求头配置
+	HeaderProfiles []cookie.HeaderProfile
+	HeaderRotation string
+
 	// 配置热加载相关
 	mu            sync.RWMutex // 读写锁，保护配置的并发访问
+	checkMu       sync.Mutex   // 保证同一时间只有一次订单检查
+	stopping      atomic.Bool  // 正在停止，聊天命令和 Web 接口不再触发新的检查或 Cookie 更新
 	configVersion int          // 配置版本号，用于跟踪配置变化
 
 	// 包管理器
-	deliveryInfo        *delivery.Info        // 交付信息管理器
-	cookieManager       *cookie.Manager       // Cookie 管理器
-	notificationHandler *notification.Handler // 通知处理器
-	database            *db.Database          // 数据库管理器
-	webServer           *web.Server           // Web 服务器
+	deliveryInfo        *delivery.Info         // 交付信息管理器
+	cookieManager       *cookie.Manager        // Cookie 管理器
+	flapDetector        *delivery.FlapDetector // 预计时间波动检测器
+	notificationHandler *notification.Handler  // 通知处理器
+	database            *db.Database           // 数据库管理器
+	webServer           *web.Server            // Web 服务器
+	metrics             *monitorMetrics        // Prometheus 指标
+
+	// 聊天命令配置
+	TelegramBotToken       string   // Telegram 机器人 Token
+	TelegramAPIBase        string   // Telegram Bot API 地址
+	TelegramAllowedChatIDs []string // 允许发送命令的 Telegram 会话 ID
+	WeComCallbackToken     string   // 企业微信应用回调 Token
+	WeComCallbackAESKey    string   // 企业微信应用回调 EncodingAESKey
+	WeComCorpID            string   // 企业微信 CorpID
+	WeComAllowedUserIDs    []string // 允许发送命令的企业微信成员 ID
+	WeComAgentID           int      // 企业微信应用 AgentId，用于主动发送命令结果
+	WeComCorpSecret        string   // 企业微信应用 Secret
+	chatDispatcher         *chat.Dispatcher
+	telegramBot            *chat.TelegramBot
+	wecomCallback          *chat.WeComCallback
 
 	// Web 服务器配置
-	WebEnabled  bool   // 是否启用 Web 服务器
-	WebPort     int    // Web 服务器端口
-	WebBasePath string // Web 服务器根路由
+	WebEnabled     bool   // 是否启用 Web 服务器
+	WebPort        int    // Web 服务器端口
+	WebBasePath    string // Web 服务器根路由
+	WebCookieToken string // Cookie 在线更新口令
+
+	// Web 访问认证配置
+	WebAuthUsername string        // 登录用户名
+	WebAuthPassword string        // 登录密码
+	WebAuthToken    string        // API 访问令牌
+	WebSessionTTL   time.Duration // 登录会话有效期
+	CalendarToken   string        // 日历订阅令牌，只能访问 /calendar.ics
+
+	// Web HTTPS 配置（修改后需要重启）
+	WebTLSCertFile     string // 证书文件路径
+	WebTLSKeyFile      string // 私钥文件路径
+	WebTLSRedirectPort int    // HTTP 重定向端口，0 表示不启用
 }
 
 // 加载或重新加载配置
@@ -78,6 +153,11 @@ func (m *Monitor) loadConfig() error {
 		checkIntervalChanged = true
 	}
 
+	// 未配置 cookie_updated_at 时 cfg.Load 使用当前时间，此时不比较更新时间
+	cookiesChanged := config.LixiangCookies != m.LixiangCookies ||
+		!config.CookieExpiresAt.Equal(m.CookieExpiresAt) ||
+		(cfg.GetString("cookie_updated_at") != "" && !config.CookieUpdatedAt.Equal(m.CookieUpdatedAt))
+
 	// 更新 Monitor 字段
 	m.OrderID = config.OrderID
 	m.LixiangCookies = config.LixiangCookies
@@ -88,11 +168,44 @@ func (m *Monitor) loadConfig() error {
 	m.EnablePeriodicNotify = config.EnablePeriodicNotify
 	m.NotificationInterval = time.Duration(config.NotificationIntervalHours) * time.Hour
 	m.AlwaysNotifyWhenApproaching = config.AlwaysNotifyWhenApproaching
+	m.DigestSchedule = config.DigestSchedule
+	m.NotificationDedupWindow = config.NotificationDedupWindow
+	m.NotificationRateBurst = config.NotificationRateBurst
+	m.NotificationRatePerHour = config.NotificationRatePerHour
+	m.FlapStableChecks = config.FlapStableChecks
+	m.FlapStableDuration = config.FlapStableDuration
+	m.FlapWindow = config.FlapWindow
 	m.Notifiers = config.Notifiers
 	m.CookieValidDays = config.CookieValidDays
+	m.CookieExpiresAt = config.CookieExpiresAt
+	m.CookieStateFile = config.CookieStateFile
+	m.CookiePersistToConfig = config.CookiePersistToConfig
+	m.KeepaliveSchedule = config.KeepaliveSchedule
+	m.KeepaliveURL = config.KeepaliveURL
+	m.HeaderProfiles = config.HeaderProfiles
+	m.HeaderRotation = config.HeaderRotation
 	m.WebEnabled = config.WebEnabled
 	m.WebPort = config.WebPort
 	m.WebBasePath = config.WebBasePath
+	m.WebCookieToken = config.WebCookieToken
+	m.WebAuthUsername = config.WebAuthUsername
+	m.WebAuthPassword = config.WebAuthPassword
+	m.WebAuthToken = config.WebAuthToken
+	m.WebSessionTTL = time.Duration(config.WebSessionHours) * time.Hour
+	m.CalendarToken = config.CalendarToken
+	m.WebTLSCertFile = config.WebTLSCertFile
+	m.WebTLSKeyFile = config.WebTLSKeyFile
+	m.WebTLSRedirectPort = config.WebTLSRedirectPort
+	m.HealthMaxMissedChecks = config.HealthMaxMissedChecks
+	m.TelegramBotToken = config.TelegramBotToken
+	m.TelegramAPIBase = config.TelegramAPIBase
+	m.TelegramAllowedChatIDs = config.TelegramAllowedChatIDs
+	m.WeComCallbackToken = config.WeComCallbackToken
+	m.WeComCallbackAESKey = config.WeComCallbackAESKey
+	m.WeComCorpID = config.WeComCorpID
+	m.WeComAllowedUserIDs = config.WeComAllowedUserIDs
+	m.WeComAgentID = config.WeComAgentID
+	m.WeComCorpSecret = config.WeComCorpSecret
 
 	// Cookie 更新时间处理
 	if !config.CookieUpdatedAt.IsZero() {
@@ -109,11 +222,32 @@ func (m *Monitor) loadConfig() error {
 		m.deliveryInfo = delivery.NewInfo(m.LockOrderTime, m.EstimateWeeksMin, m.EstimateWeeksMax)
 	}
 
-	// 同步更新 cookieManager
+	// 同步更新 flapDetector
+	if m.flapDetector != nil {
+		if m.flapDetector.Configure(m.FlapStableChecks, m.FlapStableDuration, m.FlapWindow) {
+			log.Printf("预计时间波动检测已关闭，结束暂缓状态，已确认 %s", m.flapDetector.Confirmed())
+		}
+	}
+
+	// 同步更新聊天命令白名单
+	m.updateChatAllowlist()
+
+	// 同步更新 cookieManager，Cookie 未变化时保留失败计数和预警状态
 	if m.cookieManager != nil {
-		m.cookieManager.UpdateCookie(m.LixiangCookies, m.LixiangHeaders)
-		m.cookieManager.ValidDays = m.CookieValidDays
-		m.cookieManager.UpdatedAt = m.CookieUpdatedAt
+		if cookiesChanged {
+			m.cookieManager.UpdateCookie(m.LixiangCookies, m.LixiangHeaders)
+			m.cookieManager.SetTimes(m.CookieUpdatedAt, m.CookieExpiresAt)
+		}
+		m.cookieManager.SetValidDays(m.CookieValidDays)
+		m.cookieManager.SetProfiles(m.HeaderProfiles, m.HeaderRotation)
+		if cookiesChanged {
+			m.applyCookieState()
+		}
+	}
+
+	// 同步更新 Web 访问认证
+	if m.webServer != nil {
+		m.webServer.SetAuth(m.webAuthConfig())
 	}
 
 	// 同步更新 notificationHandler
@@ -122,9 +256,10 @@ func (m *Monitor) loadConfig() error {
 			m.Notifiers,
 			m.deliveryInfo,
 			m.NotificationInterval,
-			m.EnablePeriodicNotify,
+			m.periodicNotifyEnabled(),
 			m.AlwaysNotifyWhenApproaching,
 		)
+		m.notificationHandler.UpdateLimits(m.NotificationDedupWindow, m.NotificationRateBurst, m.NotificationRatePerHour)
 	}
 
 	// 如果检查间隔变更且 cron 已经启动，返回错误提示需要重启
@@ -135,11 +270,26 @@ func (m *Monitor) loadConfig() error {
 	return nil
 }
 
+// webAuthConfig 返回 Web 访问认证配置
+func (m *Monitor) webAuthConfig() web.AuthConfig {
+	return web.AuthConfig{
+		Username:      m.WebAuthUsername,
+		Password:      m.WebAuthPassword,
+		BearerToken:   m.WebAuthToken,
+		SessionTTL:    m.WebSessionTTL,
+		CalendarToken: m.CalendarToken,
+	}
+}
+
 // 监听配置文件变化
 func (m *Monitor) watchConfig() {
 	cfg.Watch(func() {
-		// 重新加载配置
-		if err := m.loadConfig(); err != nil {
+		// 重新加载配置，持有 checkMu 避免与进行中的检查同时修改波动检测器和 Cookie 管理器
+		m.checkMu.Lock()
+		err := m.loadConfig()
+		m.checkMu.Unlock()
+		m.scheduleDigest()
+		if err != nil {
 			log.Printf("重新加载配置失败: %v", err)
 			if err.Error() == "检查间隔已变更，需要重启服务" {
 				log.Println("⚠️  检测到检查间隔变更，请手动重启服务以应用新的检查间隔")
@@ -148,6 +298,23 @@ func (m *Monitor) watchConfig() {
 		}
 
 		log.Println("✅ 配置已成功热加载")
+		m.publishCookieStatus()
+
+		// 对比配置快照，敏感配置项只提示已更新，不包含具体值
+		snapshot := cfg.Snapshot()
+		previous := m.configSnapshot
+		changes := cfg.ChangedSettings(previous, snapshot)
+		m.configSnapshot = snapshot
+
+		// 同一次写入可能触发多次文件事件；保存 Cookie 等程序自身的写入也不需要通知
+		if len(changes) == 0 {
+			log.Println("配置内容未变化，跳过配置更新通知")
+			return
+		}
+		if cfg.SelfWritten(previous, snapshot) {
+			log.Println("配置由程序自身写入（Cookie 更新），跳过配置更新通知")
+			return
+		}
 
 		// 发送配置更新通知
 		title := "⚙️ 监控服务配置已更新"
@@ -159,6 +326,9 @@ func (m *Monitor) watchConfig() {
 			len(m.Notifiers),
 			m.EnablePeriodicNotify,
 			m.NotificationInterval.Hours())
+		if len(changes) > 0 {
+			content += "\n\n变更项:\n" + strings.Join(changes, "\n")
+		}
 
 		if err := m.notificationHandler.SendCustomNotification(title, content); err != nil {
 			log.Printf("发送配置更新通知失败: %v", err)
@@ -173,36 +343,24 @@ func NewMonitor() *Monitor {
 	}
 
 	monitor := &Monitor{
-		LixiangHeaders: map[string]string{
-			"accept":             "application/json, text/plain, */*",
-			"accept-language":    "en-US,en;q=0.9,zh-CN;q=0.8,zh-TW;q=0.7,zh;q=0.6",
-			"origin":             "https://www.lixiang.com",
-			"priority":           "u=1, i",
-			"referer":            "https://www.lixiang.com/",
-			"sec-ch-ua":          `"Google Chrome";v="141", "Not?A_Brand";v="8", "Chromium";v="141"`,
-			"sec-ch-ua-mobile":   "?0",
-			"sec-ch-ua-platform": `"macOS"`,
-			"sec-fetch-dest":     "empty",
-			"sec-fetch-mode":     "cors",
-			"sec-fetch-site":     "same-site",
-			"user-agent":         "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36",
-			"x-chj-devicetype":   "1",
-			"x-chj-metadata":     `{"code":"102002"}`,
-			"x-chj-sourceurl":    "https://www.lixiang.com/?chjchannelcode=102002",
-			"x-chj-traceid":      "75697683-7eae-0fbe-ae8e-86bfa4aab99d",
-		},
-		cron:          cron.New(cron.WithSeconds()),
-		configVersion: 0,
+		LixiangHeaders: cookie.BaseHeaders(),
+		cron:           cron.New(cron.WithSeconds()),
+		configVersion:  0,
 	}
+	monitor.metrics = newMonitorMetrics(monitor)
 
 	// 加载初始配置
 	if err := monitor.loadConfig(); err != nil {
 		log.Printf("加载初始配置失败: %v", err)
 	}
+	monitor.configSnapshot = cfg.Snapshot()
 
 	// 初始化 delivery 信息管理器
 	monitor.deliveryInfo = delivery.NewInfo(monitor.LockOrderTime, monitor.EstimateWeeksMin, monitor.EstimateWeeksMax)
 
+	// 初始化预计时间波动检测器
+	monitor.flapDetector = delivery.NewFlapDetector(monitor.FlapStableChecks, monitor.FlapStableDuration, monitor.FlapWindow)
+
 	// 初始化 cookie 管理器
 	monitor.cookieManager = cookie.NewManager(
 		monitor.LixiangCookies,
@@ -210,9 +368,14 @@ func NewMonitor() *Monitor {
 		monitor.CookieValidDays,
 		monitor.CookieUpdatedAt,
 	)
+	monitor.cookieManager.SetTimes(monitor.CookieUpdatedAt, monitor.CookieExpiresAt)
+	monitor.cookieManager.SetProfiles(monitor.HeaderProfiles, monitor.HeaderRotation)
+	monitor.cookieManager.OnCookieRefreshed = monitor.persistRefreshedCookie
+	monitor.applyCookieState()
 
 	// 设置 cookie 管理器的回调函数
 	monitor.cookieManager.OnCookieExpired = func(statusCode int, message string) {
+		state := monitor.cookieManager.Snapshot()
 		title := "❌ 理想汽车 Cookie 已失效"
 		content := fmt.Sprintf("检测到 Cookie 已失效,需要立即更新！\n\n"+
 			"状态码: %d\n"+
@@ -220,9 +383,14 @@ func NewMonitor() *Monitor {
 			"失败次数: %d\n"+
 			"检测时间: %s\n\n"+
 			"⚠️  请立即更新 config.yaml 中的 lixiang_cookies 字段！",
-			statusCode, message, monitor.cookieManager.ConsecutiveFailure, time.Now().Format(utils.DateTimeFormat))
+			statusCode, message, state.ConsecutiveFailure, time.Now().Format(utils.DateTimeFormat))
+		if monitor.webServer != nil && monitor.WebCookieToken != "" {
+			content += fmt.Sprintf("\n也可以访问 Web 页面 %s/cookie 在线更新。", monitor.WebBasePath)
+		}
 
-		if err := monitor.notificationHandler.SendCustomNotification(title, content); err != nil {
+		// 失效告警不受静音影响，同一份 Cookie 只按更新时间去重一次
+		dedupKey := "cookie_expired|" + state.UpdatedAt.Format(time.RFC3339)
+		if err := monitor.notificationHandler.SendCriticalNotification(notification.EventCookie, dedupKey, title, content); err != nil {
 			log.Printf("Cookie 失效通知发送失败: %v", err)
 		}
 	}
@@ -237,7 +405,7 @@ func NewMonitor() *Monitor {
 			"请及时更新 config.yaml 中的 lixiang_cookies 字段，避免监控中断。",
 			timeDesc, expireTime, updatedAt, ageInDays)
 
-		if err := monitor.notificationHandler.SendCustomNotification(title, content); err != nil {
+		if err := monitor.notificationHandler.SendEventNotification(notification.EventCookie, "cookie_expiring|"+expireTime, title, content); err != nil {
 			log.Printf("Cookie 过期预警通知发送失败: %v", err)
 		}
 	}
@@ -247,12 +415,14 @@ func NewMonitor() *Monitor {
 		monitor.Notifiers,
 		monitor.deliveryInfo,
 		monitor.NotificationInterval,
-		monitor.EnablePeriodicNotify,
+		monitor.periodicNotifyEnabled(),
 		monitor.AlwaysNotifyWhenApproaching,
 	)
+	monitor.notificationHandler.UpdateLimits(monitor.NotificationDedupWindow, monitor.NotificationRateBurst, monitor.NotificationRatePerHour)
+	monitor.notificationHandler.OnSendResult = monitor.onNotificationSent
 
 	// 初始化数据库
-	database, err := db.New("./lixiang-monitor.db")
+	database, err := db.New(cfg.GetPaths().DBPath)
 	if err != nil {
 		log.Printf("⚠️  数据库初始化失败: %v (历史记录功能将不可用)", err)
 	} else {
@@ -271,6 +441,46 @@ func NewMonitor() *Monitor {
 		}
 	}
 
+	// 启用 HTTPS，证书无效时不启动 Web 服务器，避免以明文 HTTP 暴露
+	if monitor.webServer != nil && (monitor.WebTLSCertFile != "" || monitor.WebTLSKeyFile != "") {
+		err := monitor.webServer.SetTLS(web.TLSConfig{
+			CertFile:     monitor.WebTLSCertFile,
+			KeyFile:      monitor.WebTLSKeyFile,
+			RedirectPort: monitor.WebTLSRedirectPort,
+		})
+		if err != nil {
+			log.Printf("⚠️  HTTPS 配置无效，Web 服务器将不会启动: %v", err)
+			monitor.webServer = nil
+		} else {
+			log.Println("✅ HTTPS 已启用")
+		}
+	}
+
+	// 启用 Prometheus 指标接口和就绪检查
+	if monitor.webServer != nil {
+		monitor.webServer.SetMetrics(monitor.metrics.registry.Handler())
+		monitor.webServer.SetHealthChecker(monitor)
+		monitor.webServer.SetDeliveryWindow(monitor)
+	}
+
+	// 启用 Web 访问认证
+	if monitor.webServer != nil {
+		auth := monitor.webAuthConfig()
+		monitor.webServer.SetAuth(auth)
+		if auth.Enabled() {
+			log.Println("✅ Web 访问认证已启用")
+		}
+	}
+
+	// 启用 Cookie 在线更新
+	if monitor.webServer != nil && monitor.WebCookieToken != "" {
+		monitor.webServer.SetCookieUpdater(monitor, monitor.WebCookieToken)
+		log.Println("✅ Cookie 在线更新已启用")
+	}
+
+	// 初始化聊天命令
+	monitor.initChat()
+
 	// 启动配置文件监听
 	monitor.watchConfig()
 
@@ -284,7 +494,7 @@ func NewMonitor() *Monitor {
 }
 
 // parseOrderResponse 解析订单响应数据
-func (m *Monitor) parseOrderResponse(rawData interface{}) (estimateTime string, err error) {
+func parseOrderResponse(rawData interface{}) (estimateTime string, err error) {
 	// 将 interface{} 转换为 map[string]interface{}
 	orderDataMap, ok := rawData.(map[string]interface{})
 	if !ok {
@@ -327,40 +537,110 @@ func (m *Monitor) logDeliveryInfo(lockOrderTime time.Time, isApproaching bool, a
 	}
 }
 
-// handleDeliveryNotification 处理交付通知逻辑
-func (m *Monitor) handleDeliveryNotification(orderID, currentEstimateTime, lastEstimateTime string, isApproaching bool, approachMsg string) {
+// handleDeliveryNotification 处理交付通知逻辑，返回预计时间是否发生变化（首次检查视为变化）
+func (m *Monitor) handleDeliveryNotification(orderID, currentEstimateTime, lastEstimateTime string, isApproaching bool, approachMsg string) bool {
 	timeChanged := false
 	notificationSent := false
 
 	if lastEstimateTime == "" {
 		// 首次检查
-		if err := m.notificationHandler.HandleFirstCheck(orderID, currentEstimateTime, isApproaching, approachMsg); err != nil {
+		if err := m.notificationHandler.HandleFirstCheck(orderID, currentEstimateTime, isApproaching, approachMsg); errors.Is(err, notification.ErrSuppressed) {
+			log.Printf("首次检查通知未发送: %v", err)
+		} else if err != nil {
 			log.Printf("处理首次检查通知失败: %v", err)
 		} else {
 			notificationSent = true
 		}
+		m.flapDetector.SetConfirmed(currentEstimateTime)
+		m.updateLastEstimateTime(currentEstimateTime)
+	} else if m.flapDetector.Enabled() && m.flapDetector.Holding() {
+		// 预计时间波动中，等待新值稳定
+		timeChanged = currentEstimateTime != lastEstimateTime
+		notificationSent = m.handleFlapObservation(orderID, currentEstimateTime, isApproaching, approachMsg)
 		m.updateLastEstimateTime(currentEstimateTime)
 	} else if currentEstimateTime != lastEstimateTime {
 		// 时间发生变化
 		timeChanged = true
-		if err := m.notificationHandler.HandleTimeChanged(orderID, currentEstimateTime, lastEstimateTime, isApproaching, approachMsg); err != nil {
-			log.Printf("处理时间变更通知失败: %v", err)
+		if m.flapDetector.Enabled() && m.isEstimateOscillating(orderID, currentEstimateTime, lastEstimateTime) {
+			log.Printf("⚠️  检测到预计交付时间来回跳动 (%s → %s)，暂缓通知直至稳定", lastEstimateTime, currentEstimateTime)
+			m.flapDetector.Hold(currentEstimateTime, lastEstimateTime, time.Now())
 		} else {
-			notificationSent = true
+			if err := m.notificationHandler.HandleTimeChanged(orderID, currentEstimateTime, lastEstimateTime, isApproaching, approachMsg); errors.Is(err, notification.ErrSuppressed) {
+				log.Printf("时间变更通知未发送: %v", err)
+			} else if err != nil {
+				log.Printf("处理时间变更通知失败: %v", err)
+			} else {
+				notificationSent = true
+			}
+			m.flapDetector.SetConfirmed(currentEstimateTime)
 		}
 		m.updateLastEstimateTime(currentEstimateTime)
 	} else {
 		// 时间未变化，检查是否需要定期通知
 		log.Println("交付时间未发生变化")
+		lastNotified := m.notificationHandler.GetLastNotificationTime()
 		if err := m.notificationHandler.HandlePeriodicNotification(orderID, currentEstimateTime, isApproaching, approachMsg); err != nil {
 			log.Printf("处理定期通知失败: %v", err)
 		}
-		// 定期通知也算作已发送通知
-		notificationSent = m.EnablePeriodicNotify
+		notificationSent = !m.notificationHandler.GetLastNotificationTime().Equal(lastNotified)
 	}
 
 	// 保存记录到数据库
 	m.saveDeliveryRecord(orderID, currentEstimateTime, lastEstimateTime, isApproaching, approachMsg, timeChanged, notificationSent)
+	return timeChanged || lastEstimateTime == ""
+}
+
+// isEstimateOscillating 根据历史变更记录判断预计时间是否在来回跳动
+func (m *Monitor) isEstimateOscillating(orderID, currentEstimateTime, lastEstimateTime string) bool {
+	if m.database == nil {
+		return false
+	}
+
+	records, err := m.database.GetTimeChangedRecords(orderID, 20)
+	if err != nil {
+		log.Printf("查询时间变更记录失败: %v", err)
+		return false
+	}
+
+	// 只回溯检测窗口内的变更
+	var recentEstimates []string
+	for _, record := range records {
+		if m.flapDetector.Window > 0 && time.Since(record.CheckTime) > m.flapDetector.Window {
+			break
+		}
+		recentEstimates = append(recentEstimates, record.PreviousEstimate, record.EstimateTime)
+	}
+
+	return m.flapDetector.IsOscillating(currentEstimateTime, lastEstimateTime, recentEstimates)
+}
+
+// handleFlapObservation 处理波动期间的检查结果，新值稳定后发送确认通知
+func (m *Monitor) handleFlapObservation(orderID, currentEstimateTime string, isApproaching bool, approachMsg string) bool {
+	now := time.Now()
+	if !m.flapDetector.Observe(currentEstimateTime, now) {
+		_, checks, _, _ := m.flapDetector.PendingInfo(now)
+		log.Printf("预计交付时间波动中，等待稳定 (%s 已连续 %d 次)", currentEstimateTime, checks)
+		return false
+	}
+
+	pending, checks, stableFor, seen := m.flapDetector.PendingInfo(now)
+	confirmed := m.flapDetector.Confirmed()
+	m.flapDetector.Release()
+
+	if pending == confirmed {
+		log.Printf("预计交付时间波动结束，已恢复为 %s，无需通知", pending)
+		return false
+	}
+
+	err := m.notificationHandler.HandleTimeConfirmed(orderID, pending, confirmed, seen, checks, stableFor, isApproaching, approachMsg)
+	if errors.Is(err, notification.ErrSuppressed) {
+		log.Printf("确认变更通知未发送: %v", err)
+		return false
+	} else if err != nil {
+		log.Printf("处理确认变更通知失败: %v", err)
+		return false
+	}
+	return true
 }
 
 // updateLastEstimateTime 更新最后的预估时间
@@ -395,7 +675,127 @@ func (m *Monitor) saveDeliveryRecord(orderID, currentEstimateTime, previousEstim
 	}
 }
 
-func (m *Monitor) checkDeliveryTime() {
+// saveCheckFailure 保存检查失败记录到数据库
+func (m *Monitor) saveCheckFailure(orderID, kind string, checkErr error) {
+	if m.database == nil {
+		return
+	}
+
+	failure := &db.CheckFailure{
+		OrderID:   orderID,
+		Kind:      kind,
+		Message:   checkErr.Error(),
+		CheckTime: time.Now(),
+	}
+
+	if err := m.database.SaveCheckFailure(failure); err != nil {
+		log.Printf("保存检查失败记录失败: %v", err)
+	}
+}
+
+// sendDigest 汇总上一个周期的检查情况并发送摘要报告
+// 与订单检查共用 checkMu，避免同时读写通知处理器的最后通知时间和交付信息
+func (m *Monitor) sendDigest() {
+	m.checkMu.Lock()
+	defer m.checkMu.Unlock()
+
+	if m.database == nil {
+		log.Println("数据库未初始化，跳过摘要报告")
+		return
+	}
+
+	m.mu.RLock()
+	orderID := m.OrderID
+	schedule := m.DigestSchedule
+	since := m.lastDigestTime
+	m.mu.RUnlock()
+
+	until := time.Now()
+	if since.IsZero() {
+		since = until.Add(-digestPeriod(schedule, until))
+	}
+
+	summary, err := m.database.GetPeriodSummary(orderID, since, until)
+	if err != nil {
+		log.Printf("汇总检查记录失败: %v", err)
+		return
+	}
+
+	if err := m.notificationHandler.HandleDigest(orderID, since, until, summary, m.cookieManager.GetStatus()); err != nil {
+		log.Printf("处理摘要报告失败: %v", err)
+		return
+	}
+
+	m.mu.Lock()
+	m.lastDigestTime = until
+	m.mu.Unlock()
+}
+
+// digestPeriod 根据摘要报告的 cron 表达式推算一个周期的长度
+func digestPeriod(schedule string, now time.Time) time.Duration {
+	parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+	sched, err := parser.Parse(schedule)
+	if err != nil {
+		return 24 * time.Hour
+	}
+
+	next := sched.Next(now)
+	return sched.Next(next).Sub(next)
+}
+
+// periodicNotifyEnabled 是否发送定期通知（摘要报告任务已调度时由摘要报告取代）
+// 调用方需持有 m.mu
+func (m *Monitor) periodicNotifyEnabled() bool {
+	return m.EnablePeriodicNotify && m.digestEntryID == 0
+}
+
+// scheduleDigest 按当前的 digest_schedule 添加、替换或移除摘要报告任务，并同步定期通知开关
+// 启动时和配置热加载后调用；表达式无效时不调度，继续发送定期通知
+func (m *Monitor) scheduleDigest() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.DigestSchedule != m.digestSpec || (m.DigestSchedule != "" && m.digestEntryID == 0) {
+		if m.digestEntryID != 0 {
+			m.cron.Remove(m.digestEntryID)
+			m.digestEntryID = 0
+			m.digestSpec = ""
+			log.Println("摘要报告任务已移除")
+		}
+
+		if m.DigestSchedule != "" {
+			entryID, err := m.cron.AddFunc(m.DigestSchedule, m.sendDigest)
+			if err != nil {
+				log.Printf("警告: 添加摘要报告任务失败: %v，继续发送定期通知", err)
+			} else {
+				m.digestEntryID = entryID
+				m.digestSpec = m.DigestSchedule
+				log.Printf("摘要报告已启用: %s", m.DigestSchedule)
+			}
+		}
+	}
+
+	if m.notificationHandler != nil {
+		m.notificationHandler.SetPeriodicNotify(m.periodicNotifyEnabled())
+	}
+}
+
+// checkResult 一次订单检查的结果
+type checkResult int
+
+const (
+	checkUnchanged     checkResult = iota // 预计时间未变化
+	checkChanged                          // 预计时间发生变化（含首次检查）
+	checkCookieExpired                    // Cookie 已失效
+	checkAPIError                         // 接口请求或解析失败
+)
+
+// checkDeliveryTime 执行一次订单检查
+func (m *Monitor) checkDeliveryTime() (result checkResult) {
+	m.checkMu.Lock()
+	defer m.checkMu.Unlock()
+	defer func() { m.recordCheckResult(result) }()
+
 	log.Println("开始检查订单交付时间...")
 
 	// 获取订单数据
@@ -403,21 +803,26 @@ func (m *Monitor) checkDeliveryTime() {
 	orderID := m.OrderID
 	m.mu.RUnlock()
 
+	fetchStart := time.Now()
 	rawData, err := m.cookieManager.FetchOrderData(orderID)
+	m.metrics.fetchDuration.Observe(time.Since(fetchStart).Seconds())
 	if err != nil {
 		if _, isCookieError := err.(*cookie.CookieExpiredError); isCookieError {
 			log.Printf("⚠️  Cookie 已失效，跳过本次检查: %v", err)
-			return
+			m.saveCheckFailure(orderID, db.FailureCookieExpired, err)
+			return checkCookieExpired
 		}
 		log.Printf("获取订单数据失败: %v", err)
-		return
+		m.saveCheckFailure(orderID, db.FailureAPIError, err)
+		return checkAPIError
 	}
 
 	// 解析订单响应
-	currentEstimateTime, err := m.parseOrderResponse(rawData)
+	currentEstimateTime, err := parseOrderResponse(rawData)
 	if err != nil {
 		log.Printf("%v", err)
-		return
+		m.saveCheckFailure(orderID, db.FailureAPIError, err)
+		return checkAPIError
 	}
 
 	log.Printf("当前预计交付时间: %s", currentEstimateTime)
@@ -435,24 +840,206 @@ func (m *Monitor) checkDeliveryTime() {
 	m.logDeliveryInfo(lockOrderTime, isApproaching, approachMsg)
 
 	// 处理通知逻辑
-	m.handleDeliveryNotification(orderID, currentEstimateTime, lastEstimateTime, isApproaching, approachMsg)
+	if !m.handleDeliveryNotification(orderID, currentEstimateTime, lastEstimateTime, isApproaching, approachMsg) {
+		return checkUnchanged
+	}
+	if lastEstimateTime != "" {
+		m.metrics.estimateChanges.Inc()
+		m.publishEvent(web.EventEstimate, map[string]string{
+			"previous": lastEstimateTime,
+			"current":  currentEstimateTime,
+		})
+	}
+	return checkChanged
+}
+
+// recordCheckResult 记录检查结果：更新指标和最近成功检查时间，并向仪表板推送检查完成和 Cookie 状态事件
+func (m *Monitor) recordCheckResult(result checkResult) {
+	m.metrics.observeCheck(result)
+
+	m.mu.Lock()
+	if result == checkChanged || result == checkUnchanged {
+		m.lastSuccessfulCheck = time.Now()
+	}
+	estimateTime := m.LastEstimateTime
+	m.mu.Unlock()
+
+	m.publishEvent(web.EventCheck, map[string]string{
+		"result":        result.label(),
+		"estimate_time": estimateTime,
+	})
+	m.publishCookieStatus()
+}
+
+// loadLastState 从数据库恢复上次的预计时间和通知时间，用于单次检查模式
+func (m *Monitor) loadLastState() error {
+	if m.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	m.mu.RLock()
+	orderID := m.OrderID
+	m.mu.RUnlock()
+
+	// Cookie 失败计数和通知状态，Cookie 失效时没有检查记录，需在按历史记录判断首次检查之前恢复
+	var failureState cookie.FailureState
+	if found, err := m.loadState(cookieFailureStateKey, &failureState); err != nil {
+		return err
+	} else if found && m.cookieManager.RestoreFailureState(failureState) && failureState.ConsecutiveFailure > 0 {
+		log.Printf("已恢复 Cookie 失败计数: 连续失败 %d 次", failureState.ConsecutiveFailure)
+	}
+
+	latest, err := m.database.GetLatestRecord(orderID)
+	if err != nil {
+		return err
+	}
+	if latest == nil {
+		log.Println("数据库中没有历史记录，按首次检查处理")
+		return nil
+	}
+	m.updateLastEstimateTime(latest.EstimateTime)
+
+	notified, err := m.database.GetLastNotifiedRecord(orderID)
+	if err != nil {
+		return err
+	}
+	if notified != nil {
+		m.notificationHandler.SetLastNotificationTime(notified.CheckTime)
+	}
+
+	// 波动检测的已确认值和暂缓状态，没有保存过时以最新记录为已确认值
+	var flapState delivery.FlapState
+	found, err := m.loadState(flapStateKey(orderID), &flapState)
+	if err != nil {
+		return err
+	}
+	if found {
+		m.flapDetector.Restore(flapState)
+		if m.flapDetector.Holding() {
+			log.Printf("已恢复波动暂缓状态: 已确认 %s，等待确认 %s", flapState.Confirmed, flapState.Pending)
+		}
+	} else {
+		m.flapDetector.SetConfirmed(latest.EstimateTime)
+	}
+
+	var limiterState notification.LimiterState
+	if found, err := m.loadState(limiterStateKey, &limiterState); err != nil {
+		return err
+	} else if found {
+		m.notificationHandler.RestoreLimiterState(limiterState)
+	}
+
+	log.Printf("已恢复上次状态: 预计时间 %s (检查于 %s)", latest.EstimateTime, latest.CheckTime.Format(utils.DateTimeFormat))
+	return nil
+}
+
+// 运行状态在 monitor_state 表中的键
+const (
+	limiterStateKey       = "notification_limiter"
+	cookieFailureStateKey = "cookie_failures"
+)
+
+// flapStateKey 波动检测状态的键，按订单区分
+func flapStateKey(orderID string) string {
+	return "flap_detector:" + orderID
+}
+
+// saveLastState 保存波动检测、通知去重限流和 Cookie 失败计数状态，供单次检查模式的下一次运行恢复
+func (m *Monitor) saveLastState() error {
+	if m.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	m.mu.RLock()
+	orderID := m.OrderID
+	m.mu.RUnlock()
+
+	if err := m.saveState(flapStateKey(orderID), m.flapDetector.State()); err != nil {
+		return err
+	}
+	if err := m.saveState(cookieFailureStateKey, m.cookieManager.FailureState()); err != nil {
+		return err
+	}
+	return m.saveState(limiterStateKey, m.notificationHandler.LimiterState())
+}
+
+// checkOnce 单次检查模式的完整流程：恢复上次状态，检查订单和 Cookie 过期，再保存状态供下一次运行恢复
+func (m *Monitor) checkOnce() (checkResult, error) {
+	if err := m.loadLastState(); err != nil {
+		return checkAPIError, fmt.Errorf("恢复上次状态失败: %v", err)
+	}
+
+	result := m.checkDeliveryTime()
+	m.cookieManager.CheckExpiration()
+
+	if err := m.saveLastState(); err != nil {
+		log.Printf("⚠️  保存运行状态失败，下次运行将无法恢复波动暂缓、去重和 Cookie 失败计数状态: %v", err)
+	}
+	return result, nil
+}
+
+// loadState 读取并解析保存的运行状态，返回是否存在
+func (m *Monitor) loadState(key string, v interface{}) (bool, error) {
+	value, err := m.database.GetState(key)
+	if err != nil {
+		return false, err
+	}
+	if value == "" {
+		return false, nil
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return false, fmt.Errorf("解析运行状态 %s 失败: %v", key, err)
+	}
+	return true, nil
+}
+
+// saveState 序列化并保存运行状态
+func (m *Monitor) saveState(key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("序列化运行状态 %s 失败: %v", key, err)
+	}
+	return m.database.SaveState(key, string(data))
 }
 
 func (m *Monitor) Start() error {
 	log.Printf("启动监控服务，检查间隔: %s", m.CheckInterval)
 
+	// 先注册退出信号，启动过程中（如首次检查等待接口响应时）收到信号也走优雅退出
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	m.mu.Lock()
+	m.startedAt = time.Now()
+	m.mu.Unlock()
+
 	// 立即执行一次检查
-	m.checkDeliveryTime()
+	initialCheck := make(chan struct{})
+	go func() {
+		defer close(initialCheck)
+		m.checkDeliveryTime()
+	}()
+	select {
+	case <-initialCheck:
+	case sig := <-sigCh:
+		log.Printf("启动过程中收到信号 %s，开始优雅退出...", sig)
+		m.Stop()
+		return nil
+	}
 
 	// 立即检查 Cookie 过期状态并显示状态
 	log.Printf("Cookie 状态: %s", m.cookieManager.GetStatus())
 	m.cookieManager.CheckExpiration()
 
 	// 添加定时任务 - 订单检查
-	_, err := m.cron.AddFunc(m.CheckInterval, m.checkDeliveryTime)
+	entryID, err := m.cron.AddFunc(m.CheckInterval, func() { m.checkDeliveryTime() })
 	if err != nil {
 		return fmt.Errorf("添加定时任务失败: %v", err)
 	}
+	m.mu.Lock()
+	m.checkEntryID = entryID
+	m.mu.Unlock()
 
 	// 添加定时任务 - 每日检查 Cookie 过期（凌晨 1 点）
 	_, err = m.cron.AddFunc("0 0 1 * * *", func() {
@@ -463,6 +1050,18 @@ func (m *Monitor) Start() error {
 		log.Printf("警告: 添加 Cookie 过期检查任务失败: %v", err)
 	}
 
+	// 添加定时任务 - 摘要报告
+	m.scheduleDigest()
+
+	// 添加定时任务 - Cookie 会话保活
+	if m.KeepaliveSchedule != "" {
+		if _, err := m.cron.AddFunc(m.KeepaliveSchedule, m.keepaliveCookie); err != nil {
+			log.Printf("警告: 添加会话保活任务失败: %v", err)
+		} else {
+			log.Printf("会话保活已启用: %s", m.KeepaliveSchedule)
+		}
+	}
+
 	m.cron.Start()
 
 	// 启动 Web 服务器
@@ -472,24 +1071,74 @@ func (m *Monitor) Start() error {
 		}
 	}
 
+	// 启动 Telegram 命令轮询
+	if m.telegramBot != nil {
+		m.telegramBot.Start()
+	}
+
 	log.Println("监控服务已启动，等待定时检查...")
 
-	// 保持程序运行
-	select {}
+	// 等待退出信号
+	sig := <-sigCh
+	log.Printf("收到信号 %s，开始优雅退出...", sig)
+
+	m.Stop()
+	return nil
 }
 
+// Stop 优雅停止监控服务
+// 先停止调度新的检查并等待正在进行的检查和通知完成，再关闭 Web 服务器和数据库，两个阶段各自最多等待 shutdownTimeout
 func (m *Monitor) Stop() {
 	log.Println("停止监控服务...")
-	m.cron.Stop()
 
-	// 停止 Web 服务器
+	// 停止调度新的定时任务，并停止接收聊天命令；
+	// 仍在处理中的 Web 请求和企业微信命令不再触发新的检查或 Cookie 更新
+	m.stopping.Store(true)
+	cronDone := m.cron.Stop()
+	if m.telegramBot != nil {
+		m.telegramBot.Stop()
+	}
+
+	// 等待正在运行的定时任务（检查、摘要报告、会话保活）及其通知完成。
+	// 只等待不持有 checkMu，已在等待 checkMu 的 Web 请求可以继续执行并在下一阶段结束
+	checkCtx, cancelCheck := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelCheck()
+	checkDone := make(chan struct{})
+	go func() {
+		<-cronDone.Done()
+		m.checkMu.Lock()
+		m.checkMu.Unlock()
+		close(checkDone)
+	}()
+	select {
+	case <-checkDone:
+	case <-checkCtx.Done():
+		log.Printf("⚠️  等待正在进行的检查超时 (%s)，继续关闭", shutdownTimeout)
+	}
+
+	// 不再接受新的 Web 请求，等待正在处理的请求（如 Cookie 更新、企业微信命令）完成。
+	// 使用新的超时，避免等待检查时已耗尽时间导致请求被直接中断
+	webCtx, cancelWeb := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelWeb()
 	if m.webServer != nil {
-		if err := m.webServer.Stop(); err != nil {
+		if err := m.webServer.Stop(webCtx); err != nil {
 			log.Printf("关闭 Web 服务器失败: %v", err)
 		}
 	}
+	// 企业微信命令在回调返回后继续在后台执行，同样等待其完成
+	if m.wecomCallback != nil {
+		if err := m.wecomCallback.Wait(webCtx); err != nil {
+			log.Printf("等待企业微信命令完成超时: %v", err)
+		}
+	}
 
 	// 关闭数据库连接
+	m.closeDatabase()
+	log.Println("✅ 监控服务已停止")
+}
+
+// closeDatabase 关闭数据库连接
+func (m *Monitor) closeDatabase() {
 	if m.database != nil {
 		if err := m.database.Close(); err != nil {
 			log.Printf("关闭数据库连接失败: %v", err)
@@ -499,21 +1148,5 @@ func (m *Monitor) Stop() {
 
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
-
-	monitor := NewMonitor()
-
-	// 检查配置
-	if len(monitor.Notifiers) == 0 {
-		log.Println("警告: 未配置任何通知器，将不会发送通知")
-	}
-
-	if monitor.LixiangCookies == "" {
-		log.Println("警告: 未配置理想汽车 Cookies，可能导致请求失败")
-	}
-
-	// 启动监控
-	if err := monitor.Start(); err != nil {
-		log.Fatalf("启动监控服务失败: %v", err)
-		os.Exit(1)
-	}
+	os.Exit(runCLI(os.Args[1:]))
 }
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"lixiang-monitor/cookie"
+	"lixiang-monitor/db"
+	"lixiang-monitor/delivery"
+	"lixiang-monitor/notification"
+	"lixiang-monitor/notifier"
+)
+
+// recordingNotifier 记录发送的通知标题
+type recordingNotifier struct {
+	titles []string
+}
+
+func (n *recordingNotifier) Send(title, content string) error {
+	n.titles = append(n.titles, title)
+	return nil
+}
+
+// newOnceMonitor 按 once 命令的方式新建 Monitor，每次调用相当于一个新进程
+func newOnceMonitor(database *db.Database, n notifier.Notifier) *Monitor {
+	m := &Monitor{
+		OrderID:       "order-1",
+		database:      database,
+		flapDetector:  delivery.NewFlapDetector(2, 0, 24*time.Hour),
+		cookieManager: cookie.NewManager("X-LX-Token=abc", nil, 0, time.Now()),
+		notificationHandler: notification.NewHandler(
+			[]notifier.Notifier{n},
+			delivery.NewInfo(time.Now(), 4, 6),
+			time.Hour, false, false,
+		),
+	}
+	m.metrics = newMonitorMetrics(m)
+	m.notificationHandler.UpdateLimits(24*time.Hour, 0, 0)
+	return m
+}
+
+// runOnceCheck 模拟一次 once 调用：新建 Monitor、恢复上次状态、处理本次预计时间并保存状态
+func runOnceCheck(t *testing.T, dbPath, estimate string, n notifier.Notifier) {
+	t.Helper()
+
+	database, err := db.New(dbPath)
+	if err != nil {
+		t.Fatalf("db.New() error = %v", err)
+	}
+	defer database.Close()
+
+	m := newOnceMonitor(database, n)
+
+	if err := m.loadLastState(); err != nil {
+		t.Fatalf("loadLastState() error = %v", err)
+	}
+	m.handleDeliveryNotification(m.OrderID, estimate, m.LastEstimateTime, false, "")
+	if err := m.saveLastState(); err != nil {
+		t.Fatalf("saveLastState() error = %v", err)
+	}
+}
+
+func TestOnceKeepsFlapHoldAcrossRuns(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "monitor.db")
+	n := &recordingNotifier{}
+
+	// A（首次）→ B（通知变更）→ A（回跳，暂缓）→ A（稳定，确认变更）
+	for _, estimate := range []string{"A", "B", "A", "A"} {
+		runOnceCheck(t, dbPath, estimate, n)
+	}
+
+	want := []string{
+		notification.TitleMonitorStarted,
+		notification.TitleTimeChanged,
+		notification.TitleTimeConfirmed,
+	}
+	if !reflect.DeepEqual(n.titles, want) {
+		t.Errorf("notifications = %q, want %q", n.titles, want)
+	}
+}
+
+// unauthorizedTransport 所有请求都返回 401，模拟 Cookie 已失效
+type unauthorizedTransport struct{}
+
+func (unauthorizedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusUnauthorized,
+		Body:       io.NopCloser(strings.NewReader("unauthorized")),
+		Request:    req,
+	}, nil
+}
+
+func TestOnceSendsCookieAlertOnThirdFailure(t *testing.T) {
+	// Cookie 管理器的 HTTP 客户端使用默认 Transport
+	transport := http.DefaultTransport
+	http.DefaultTransport = unauthorizedTransport{}
+	t.Cleanup(func() { http.DefaultTransport = transport })
+
+	dbPath := filepath.Join(t.TempDir(), "monitor.db")
+	alerts := 0
+	for run := 1; run <= 3; run++ {
+		database, err := db.New(dbPath)
+		if err != nil {
+			t.Fatalf("db.New() error = %v", err)
+		}
+
+		m := newOnceMonitor(database, &recordingNotifier{})
+		m.cookieManager.OnCookieExpired = func(statusCode int, message string) { alerts++ }
+
+		result, err := m.checkOnce()
+		database.Close()
+		if err != nil {
+			t.Fatalf("run %d: checkOnce() error = %v", run, err)
+		}
+		if result != checkCookieExpired {
+			t.Fatalf("run %d: result = %d, want checkCookieExpired", run, result)
+		}
+
+		want := 0
+		if run == 3 {
+			want = 1
+		}
+		if alerts != want {
+			t.Errorf("run %d: alerts = %d, want %d", run, alerts, want)
+		}
+	}
+}
+
+func TestApplyCookieStateWithoutConfiguredUpdatedAt(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "cookie_state.json")
+	refreshedAt := time.Now().Add(-time.Hour)
+	if err := cookie.SaveState(stateFile, &cookie.State{
+		Cookies:   "X-LX-Token=refreshed",
+		Origin:    cookie.OriginHash("X-LX-Token=original"),
+		UpdatedAt: refreshedAt,
+	}); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	// 未配置 cookie_updated_at 时加载配置得到的更新时间是当前时间，晚于状态文件
+	m := &Monitor{
+		LixiangCookies:  "X-LX-Token=original",
+		CookieUpdatedAt: time.Now(),
+		CookieStateFile: stateFile,
+	}
+	m.cookieManager = cookie.NewManager(m.LixiangCookies, nil, 0, m.CookieUpdatedAt)
+	m.applyCookieState()
+
+	if m.cookieManager.Snapshot().Cookies != "X-LX-Token=refreshed" {
+		t.Errorf("cookies = %q, want the refreshed cookie from the state file", m.cookieManager.Snapshot().Cookies)
+	}
+	if !m.cookieManager.Snapshot().UpdatedAt.Equal(refreshedAt) {
+		t.Errorf("UpdatedAt = %s, want %s", m.cookieManager.Snapshot().UpdatedAt, refreshedAt)
+	}
+
+	// 配置中换了新的 Cookie 后不再使用旧的状态文件
+	m.LixiangCookies = "X-LX-Token=replaced"
+	m.cookieManager = cookie.NewManager(m.LixiangCookies, nil, 0, m.CookieUpdatedAt)
+	m.applyCookieState()
+	if m.cookieManager.Snapshot().Cookies != "X-LX-Token=replaced" {
+		t.Errorf("cookies = %q, want the newly configured cookie", m.cookieManager.Snapshot().Cookies)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"time"
+
+	"lixiang-monitor/metrics"
+	"lixiang-monitor/notifier"
+)
+
+// monitorMetrics 监控服务的 Prometheus 指标，通过 Web 服务器的 /metrics 接口输出
+type monitorMetrics struct {
+	registry        *metrics.Registry
+	checks          *metrics.CounterVec // 按结果统计的检查次数
+	fetchDuration   *metrics.Histogram  // 订单接口请求耗时
+	notifications   *metrics.CounterVec // 按通知器和结果统计的通知次数
+	estimateChanges *metrics.CounterVec // 预计交付时间变化次数
+}
+
+// newMonitorMetrics 创建指标，交付窗口和 Cookie 剩余时间在输出时实时计算
+func newMonitorMetrics(m *Monitor) *monitorMetrics {
+	registry := metrics.NewRegistry()
+	mm := &monitorMetrics{
+		registry: registry,
+		checks: registry.NewCounterVec("lixiang_monitor_checks_total",
+			"订单检查次数，outcome 为 unchanged、changed、cookie_expired 或 api_error", "outcome"),
+		fetchDuration: registry.NewHistogram("lixiang_monitor_fetch_duration_seconds",
+			"订单接口请求耗时（秒）", metrics.DefaultBuckets),
+		notifications: registry.NewCounterVec("lixiang_monitor_notifications_total",
+			"通知发送次数，result 为 sent 或 failed", "notifier", "result"),
+		estimateChanges: registry.NewCounterVec("lixiang_monitor_estimate_changes_total",
+			"预计交付时间变化次数"),
+	}
+
+	// 预先输出各检查结果的 0 值，便于 rate()/increase() 计算
+	for _, result := range []checkResult{checkUnchanged, checkChanged, checkCookieExpired, checkAPIError} {
+		mm.checks.Add(0, result.label())
+	}
+
+	registry.NewGaugeFunc("lixiang_monitor_delivery_window_min_seconds",
+		"距离预计交付窗口开始的秒数，已过时为负数", func() (float64, bool) {
+			minDate, _, ok := m.DeliveryWindow()
+			return time.Until(minDate).Seconds(), ok
+		})
+	registry.NewGaugeFunc("lixiang_monitor_delivery_window_max_seconds",
+		"距离预计交付窗口结束的秒数，已过时为负数", func() (float64, bool) {
+			_, maxDate, ok := m.DeliveryWindow()
+			return time.Until(maxDate).Seconds(), ok
+		})
+	registry.NewGaugeFunc("lixiang_monitor_delivery_progress_percent",
+		"交付进度百分比（0-100）", func() (float64, bool) {
+			m.mu.RLock()
+			defer m.mu.RUnlock()
+			if m.deliveryInfo == nil || m.LockOrderTime.IsZero() {
+				return 0, false
+			}
+			return m.deliveryInfo.CalculateDeliveryProgress(), true
+		})
+	registry.NewGaugeFunc("lixiang_monitor_cookie_remaining_seconds",
+		"Cookie 剩余有效时间（秒），已过期时为负数", func() (float64, bool) {
+			if m.cookieManager == nil {
+				return 0, false
+			}
+			expiry, _ := m.cookieManager.ExpiryTime()
+			if expiry.IsZero() {
+				return 0, false
+			}
+			return time.Until(expiry).Seconds(), true
+		})
+
+	return mm
+}
+
+// observeCheck 记录一次检查的结果
+func (mm *monitorMetrics) observeCheck(result checkResult) {
+	mm.checks.Inc(result.label())
+}
+
+// observeNotification 记录一次通知发送结果
+func (mm *monitorMetrics) observeNotification(n notifier.Notifier, err error) {
+	result := "sent"
+	if err != nil {
+		result = "failed"
+	}
+	mm.notifications.Inc(notifier.Kind(n), result)
+}
+
+// label 返回检查结果的指标标签值
+func (r checkResult) label() string {
+	switch r {
+	case checkChanged:
+		return "changed"
+	case checkCookieExpired:
+		return "cookie_expired"
+	case checkAPIError:
+		return "api_error"
+	default:
+		return "unchanged"
+	}
+}
+
+// DeliveryWindow 实现 web.DeliveryWindowProvider 接口，返回预计交付窗口，未配置锁单时间时 ok 为 false
+func (m *Monitor) DeliveryWindow() (minDate, maxDate time.Time, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.deliveryInfo == nil || m.LockOrderTime.IsZero() {
+		return time.Time{}, time.Time{}, false
+	}
+	minDate, maxDate = m.deliveryInfo.CalculateEstimatedDelivery()
+	return minDate, maxDate, true
+}
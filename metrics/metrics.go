@@ -0,0 +1,252 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType Prometheus 文本格式的 Content-Type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets 默认的直方图分桶（秒），适用于 HTTP 请求耗时
+var DefaultBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// collector 可以输出为 Prometheus 文本格式的指标
+type collector interface {
+	write(w io.Writer) error
+}
+
+// Registry 指标注册表，按注册顺序输出所有指标
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+// NewRegistry 创建指标注册表
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// register 注册指标
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.collectors = append(r.collectors, c)
+}
+
+// Write 以 Prometheus 文本格式输出所有指标
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	for _, c := range collectors {
+		if err := c.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler 返回输出所有指标的 HTTP 处理器
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		if err := r.Write(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// CounterVec 带标签的计数器
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64 // 标签值（以 \xff 连接）-> 计数
+}
+
+// NewCounterVec 注册带标签的计数器，labels 为空时即普通计数器
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{name: name, help: help, labels: labels, values: make(map[string]float64)}
+	r.register(c)
+	return c
+}
+
+// Inc 计数加一，labelValues 与注册时的标签一一对应
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add 计数增加 v
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if len(labelValues) != len(c.labels) {
+		panic(fmt.Sprintf("指标 %s 需要 %d 个标签值，实际 %d 个", c.name, len(c.labels), len(labelValues)))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[strings.Join(labelValues, "\xff")] += v
+}
+
+// write 输出计数器
+func (c *CounterVec) write(w io.Writer) error {
+	c.mu.Lock()
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([]float64, len(keys))
+	for i, key := range keys {
+		values[i] = c.values[key]
+	}
+	c.mu.Unlock()
+
+	if err := writeHeader(w, c.name, c.help, "counter"); err != nil {
+		return err
+	}
+	// 没有标签的计数器在未计数时也输出 0，便于告警规则判断
+	if len(c.labels) == 0 && len(keys) == 0 {
+		keys, values = []string{""}, []float64{0}
+	}
+	for i, key := range keys {
+		var labelValues []string
+		if len(c.labels) > 0 {
+			labelValues = strings.Split(key, "\xff")
+		}
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, labelValues), formatValue(values[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Histogram 直方图
+type Histogram struct {
+	name    string
+	help    string
+	buckets []float64
+
+	mu     sync.Mutex
+	counts []uint64 // 每个分桶的计数（非累计）
+	sum    float64
+	count  uint64
+}
+
+// NewHistogram 注册直方图，buckets 为升序的分桶上界
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	h := &Histogram{name: name, help: help, buckets: buckets, counts: make([]uint64, len(buckets))}
+	r.register(h)
+	return h
+}
+
+// Observe 记录一个观测值
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, upper := range h.buckets {
+		if v <= upper {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// write 输出直方图
+func (h *Histogram) write(w io.Writer) error {
+	h.mu.Lock()
+	counts := append([]uint64(nil), h.counts...)
+	sum, count := h.sum, h.count
+	h.mu.Unlock()
+
+	if err := writeHeader(w, h.name, h.help, "histogram"); err != nil {
+		return err
+	}
+	var cumulative uint64
+	for i, upper := range h.buckets {
+		cumulative += counts[i]
+		if _, err := fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", h.name, formatValue(upper), cumulative); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n%s_sum %s\n%s_count %d\n",
+		h.name, count, h.name, formatValue(sum), h.name, count); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GaugeFunc 输出时实时计算的仪表盘指标
+type GaugeFunc struct {
+	name string
+	help string
+	fn   func() (float64, bool)
+}
+
+// NewGaugeFunc 注册仪表盘指标，fn 返回 false 时不输出该指标（例如数据尚不可用）
+func (r *Registry) NewGaugeFunc(name, help string, fn func() (float64, bool)) *GaugeFunc {
+	g := &GaugeFunc{name: name, help: help, fn: fn}
+	r.register(g)
+	return g
+}
+
+// write 输出仪表盘指标
+func (g *GaugeFunc) write(w io.Writer) error {
+	value, ok := g.fn()
+	if !ok {
+		return nil
+	}
+	if err := writeHeader(w, g.name, g.help, "gauge"); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s %s\n", g.name, formatValue(value))
+	return err
+}
+
+// writeHeader 输出 HELP 和 TYPE 注释
+func writeHeader(w io.Writer, name, help, typ string) error {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	return err
+}
+
+// formatLabels 格式化标签，例如 {outcome="changed"}
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, escaper.Replace(values[i]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatValue 格式化数值
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
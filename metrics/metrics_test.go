@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// render 输出注册表中的所有指标
+func render(t *testing.T, r *Registry) string {
+	t.Helper()
+
+	var b strings.Builder
+	if err := r.Write(&b); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	return b.String()
+}
+
+func TestCounterVecOutput(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("lixiang_monitor_restarts_total", "重启次数")
+	checks := r.NewCounterVec("lixiang_monitor_checks_total", "订单检查次数，outcome 为\n检查结果 C:\\path", "outcome")
+	checks.Inc("unchanged")
+	checks.Add(2, "changed")
+	checks.Inc(`say "hi"` + "\n" + `C:\tmp`)
+
+	want := `# HELP lixiang_monitor_restarts_total 重启次数
+# TYPE lixiang_monitor_restarts_total counter
+lixiang_monitor_restarts_total 0
+# HELP lixiang_monitor_checks_total 订单检查次数，outcome 为\n检查结果 C:\\path
+# TYPE lixiang_monitor_checks_total counter
+lixiang_monitor_checks_total{outcome="changed"} 2
+lixiang_monitor_checks_total{outcome="say \"hi\"\nC:\\tmp"} 1
+lixiang_monitor_checks_total{outcome="unchanged"} 1
+`
+	if got := render(t, r); got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCounterVecMultipleLabels(t *testing.T) {
+	r := NewRegistry()
+	sent := r.NewCounterVec("lixiang_monitor_notifications_total", "通知发送次数", "notifier", "result")
+	sent.Inc("serverchan", "success")
+	sent.Inc("wechat", "failure")
+	sent.Inc("serverchan", "success")
+
+	want := `# HELP lixiang_monitor_notifications_total 通知发送次数
+# TYPE lixiang_monitor_notifications_total counter
+lixiang_monitor_notifications_total{notifier="serverchan",result="success"} 2
+lixiang_monitor_notifications_total{notifier="wechat",result="failure"} 1
+`
+	if got := render(t, r); got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHistogramOutput(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogram("lixiang_monitor_fetch_duration_seconds", "请求耗时（秒）", []float64{1, 0.5})
+	h.Observe(0.2)
+	h.Observe(0.75)
+	h.Observe(3)
+
+	want := `# HELP lixiang_monitor_fetch_duration_seconds 请求耗时（秒）
+# TYPE lixiang_monitor_fetch_duration_seconds histogram
+lixiang_monitor_fetch_duration_seconds_bucket{le="0.5"} 1
+lixiang_monitor_fetch_duration_seconds_bucket{le="1"} 2
+lixiang_monitor_fetch_duration_seconds_bucket{le="+Inf"} 3
+lixiang_monitor_fetch_duration_seconds_sum 3.95
+lixiang_monitor_fetch_duration_seconds_count 3
+`
+	if got := render(t, r); got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHistogramInfiniteObservation(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogram("lixiang_monitor_wait_seconds", "等待时间（秒）", []float64{1})
+	h.Observe(math.Inf(1))
+
+	want := `# HELP lixiang_monitor_wait_seconds 等待时间（秒）
+# TYPE lixiang_monitor_wait_seconds histogram
+lixiang_monitor_wait_seconds_bucket{le="1"} 0
+lixiang_monitor_wait_seconds_bucket{le="+Inf"} 1
+lixiang_monitor_wait_seconds_sum +Inf
+lixiang_monitor_wait_seconds_count 1
+`
+	if got := render(t, r); got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestGaugeFuncSpecialValues(t *testing.T) {
+	r := NewRegistry()
+	r.NewGaugeFunc("gauge_nan", "非数值", func() (float64, bool) { return math.NaN(), true })
+	r.NewGaugeFunc("gauge_pos_inf", "正无穷", func() (float64, bool) { return math.Inf(1), true })
+	r.NewGaugeFunc("gauge_neg_inf", "负无穷", func() (float64, bool) { return math.Inf(-1), true })
+	r.NewGaugeFunc("gauge_unavailable", "数据不可用时不输出", func() (float64, bool) { return 0, false })
+	r.NewGaugeFunc("gauge_negative", "负数", func() (float64, bool) { return -1.5e-7, true })
+
+	want := `# HELP gauge_nan 非数值
+# TYPE gauge_nan gauge
+gauge_nan NaN
+# HELP gauge_pos_inf 正无穷
+# TYPE gauge_pos_inf gauge
+gauge_pos_inf +Inf
+# HELP gauge_neg_inf 负无穷
+# TYPE gauge_neg_inf gauge
+gauge_neg_inf -Inf
+# HELP gauge_negative 负数
+# TYPE gauge_negative gauge
+gauge_negative -1.5e-07
+`
+	if got := render(t, r); got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHandlerContentType(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("lixiang_monitor_restarts_total", "重启次数")
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if got := rec.Header().Get("Content-Type"); got != ContentType {
+		t.Errorf("Content-Type = %q, want %q", got, ContentType)
+	}
+	if !strings.HasSuffix(rec.Body.String(), "lixiang_monitor_restarts_total 0\n") {
+		t.Errorf("body = %q, want the counter sample", rec.Body.String())
+	}
+}
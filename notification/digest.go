@@ -0,0 +1,99 @@
+package notification
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"lixiang-monitor/db"
+	"lixiang-monitor/utils"
+)
+
+// TitleDigest 摘要报告通知标题
+const TitleDigest = "🗓️ 理想汽车订单监控摘要"
+
+// EventDigest 摘要报告事件类型
+const EventDigest = "digest"
+
+// HandleDigest 发送时间段摘要报告
+func (h *Handler) HandleDigest(orderID string, since, until time.Time, summary *db.PeriodSummary, cookieStatus string) error {
+	content := h.buildDigestContent(orderID, since, until, summary, cookieStatus)
+
+	if err := h.sendNotification(EventDigest, "", TitleDigest, content); err != nil {
+		return fmt.Errorf("发送摘要报告失败: %w", err)
+	}
+
+	h.updateLastNotificationTime()
+	log.Printf("摘要报告已发送 (%s 至 %s)", since.Format(utils.DateTimeShort), until.Format(utils.DateTimeShort))
+	return nil
+}
+
+// buildDigestContent 构建摘要报告内容
+func (h *Handler) buildDigestContent(orderID string, since, until time.Time, summary *db.PeriodSummary, cookieStatus string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "订单号: %s\n统计区间: %s 至 %s\n\n",
+		orderID,
+		since.Format(utils.DateTimeShort),
+		until.Format(utils.DateTimeShort))
+
+	// 检查统计
+	fmt.Fprintf(&b, "🔍 检查次数: %d 次成功, %d 次失败\n", summary.Checks, summary.Failures)
+	if summary.Failures > 0 {
+		kinds := make([]string, 0, len(summary.FailuresByKind))
+		for kind, count := range summary.FailuresByKind {
+			kinds = append(kinds, fmt.Sprintf("%s %d 次", failureKindLabel(kind), count))
+		}
+		sort.Strings(kinds)
+		fmt.Fprintf(&b, "   失败原因: %s\n", strings.Join(kinds, "、"))
+	}
+	fmt.Fprintf(&b, "🔔 发送通知: %d 次\n", summary.Notifications)
+
+	// 预计时间变化
+	if len(summary.Changes) == 0 {
+		b.WriteString("🔄 预计时间变化: 无\n")
+	} else {
+		fmt.Fprintf(&b, "🔄 预计时间变化: %d 次\n", len(summary.Changes))
+		for _, change := range summary.Changes {
+			fmt.Fprintf(&b, "   %s: %s → %s\n",
+				change.CheckTime.Format(utils.DateTimeShort),
+				change.PreviousEstimate,
+				change.EstimateTime)
+		}
+	}
+	if summary.LastRecord != nil {
+		fmt.Fprintf(&b, "📦 当前官方预计时间: %s\n", summary.LastRecord.EstimateTime)
+	}
+
+	// Cookie 健康状况
+	fmt.Fprintf(&b, "🍪 Cookie 状态: %s\n", cookieStatus)
+
+	// 距离交付窗口
+	daysToMin, daysToMax, status := h.deliveryInfo.CalculateRemainingDeliveryTime()
+	minDate, maxDate := h.deliveryInfo.CalculateEstimatedDelivery()
+	fmt.Fprintf(&b, "📅 交付窗口: %s 至 %s\n", minDate.Format(utils.DateFormat), maxDate.Format(utils.DateFormat))
+	if daysToMin > 0 {
+		fmt.Fprintf(&b, "⏰ 距离交付窗口: %d-%d 天 (%s)\n", daysToMin, daysToMax, status)
+	} else {
+		fmt.Fprintf(&b, "⏰ 交付状态: %s\n", status)
+	}
+	fmt.Fprintf(&b, "📊 等待进度: %.1f%%", h.deliveryInfo.CalculateDeliveryProgress())
+
+	return b.String()
+}
+
+// failureKindLabel 返回失败类型的显示名称
+func failureKindLabel(kind string) string {
+	switch kind {
+	case db.FailureCookieExpired:
+		return "Cookie 失效"
+	case db.FailureAPIError:
+		return "接口错误"
+	case db.FailureKeepalive:
+		return "会话保活失败"
+	default:
+		return kind
+	}
+}
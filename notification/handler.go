@@ -1,9 +1,11 @@
 package notification
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"lixiang-monitor/delivery"
@@ -18,10 +20,14 @@ const (
 	// 通知标题
 	TitleMonitorStarted    = "🚗 理想汽车订单监控已启动"
 	TitleTimeChanged       = "🚗 理想汽车交付时间更新通知"
+	TitleTimeConfirmed     = "🚗 理想汽车交付时间变更（已确认）"
 	TitlePeriodicReport    = "📊 理想汽车订单状态定期报告"
 	TitleApproachingRemind = "⏰ 理想汽车交付时间提醒"
 )
 
+// ErrSuppressed 通知因去重或限流被抑制
+var ErrSuppressed = errors.New("通知已被去重或限流抑制")
+
 // Handler 通知处理器
 type Handler struct {
 	notifiers                   []notifier.Notifier
@@ -30,6 +36,14 @@ type Handler struct {
 	notificationInterval        time.Duration
 	enablePeriodicNotify        bool
 	alwaysNotifyWhenApproaching bool
+	limiter                     *Limiter
+
+	// mutedUntil 由聊天命令（Telegram 轮询、企业微信回调）写入、由检查任务读取，需持有 muteMu
+	muteMu     sync.Mutex
+	mutedUntil time.Time
+
+	// OnSendResult 每个通知器发送完成后回调，err 为 nil 表示发送成功
+	OnSendResult func(n notifier.Notifier, title string, err error)
 }
 
 // NewHandler 创建通知处理器
@@ -47,6 +61,7 @@ func NewHandler(
 		enablePeriodicNotify:        enablePeriodicNotify,
 		alwaysNotifyWhenApproaching: alwaysNotifyWhenApproaching,
 		lastNotificationTime:        time.Time{}, // 初始化为零值
+		limiter:                     NewLimiter(0, 0, 0),
 	}
 }
 
@@ -65,6 +80,43 @@ func (h *Handler) UpdateConfig(
 	h.alwaysNotifyWhenApproaching = alwaysNotifyWhenApproaching
 }
 
+// SetPeriodicNotify 设置是否发送定期通知
+func (h *Handler) SetPeriodicNotify(enabled bool) {
+	h.enablePeriodicNotify = enabled
+}
+
+// UpdateLimits 更新通知去重窗口和限流参数
+func (h *Handler) UpdateLimits(dedupWindow time.Duration, burst int, perHour float64) {
+	h.limiter.UpdateConfig(dedupWindow, burst, perHour)
+}
+
+// LimiterState 导出通知去重与限流状态
+func (h *Handler) LimiterState() LimiterState {
+	return h.limiter.State()
+}
+
+// RestoreLimiterState 恢复通知去重与限流状态
+func (h *Handler) RestoreLimiterState(state LimiterState) {
+	h.limiter.Restore(state)
+}
+
+// Mute 在指定时间之前暂停发送通知，传入零值取消静音
+func (h *Handler) Mute(until time.Time) {
+	h.muteMu.Lock()
+	defer h.muteMu.Unlock()
+	h.mutedUntil = until
+}
+
+// MutedUntil 返回静音截止时间，未静音时返回零值
+func (h *Handler) MutedUntil() time.Time {
+	h.muteMu.Lock()
+	defer h.muteMu.Unlock()
+	if time.Now().After(h.mutedUntil) {
+		return time.Time{}
+	}
+	return h.mutedUntil
+}
+
 // HandleFirstCheck 处理首次检查的通知
 func (h *Handler) HandleFirstCheck(orderID, currentEstimateTime string, isApproaching bool, approachMsg string) error {
 	log.Println("初次检查，记录当前交付时间")
@@ -74,8 +126,8 @@ func (h *Handler) HandleFirstCheck(orderID, currentEstimateTime string, isApproa
 		content += WarningPrefix + approachMsg
 	}
 
-	if err := h.sendNotification(TitleMonitorStarted, content); err != nil {
-		return fmt.Errorf("发送初始通知失败: %v", err)
+	if err := h.sendNotification(EventFirstCheck, "", TitleMonitorStarted, content); err != nil {
+		return fmt.Errorf("发送初始通知失败: %w", err)
 	}
 
 	h.updateLastNotificationTime()
@@ -99,8 +151,10 @@ func (h *Handler) HandleTimeChanged(orderID, currentEstimateTime, lastEstimateTi
 		content += WarningPrefix + approachMsg
 	}
 
-	if err := h.sendNotification(TitleTimeChanged, content); err != nil {
-		return fmt.Errorf("发送变更通知失败: %v", err)
+	// 以变更前后的时间作为去重键，避免预计时间来回跳动时重复推送
+	dedupKey := fmt.Sprintf("%s|%s|%s->%s", EventTimeChanged, orderID, lastEstimateTime, currentEstimateTime)
+	if err := h.sendNotification(EventTimeChanged, dedupKey, TitleTimeChanged, content); err != nil {
+		return fmt.Errorf("发送变更通知失败: %w", err)
 	}
 
 	h.updateLastNotificationTime()
@@ -117,6 +171,32 @@ func (h *Handler) buildTimeChangedContent(orderID, lastEstimateTime, currentEsti
 		h.deliveryInfo.GetDetailedDeliveryInfo())
 }
 
+// HandleTimeConfirmed 处理波动结束后确认的交付时间变更通知
+func (h *Handler) HandleTimeConfirmed(orderID, currentEstimateTime, confirmedEstimateTime string, seen []string, stableChecks int, stableFor time.Duration, isApproaching bool, approachMsg string) error {
+	log.Printf("交付时间波动结束，确认变更: 从 %s 变更为 %s", confirmedEstimateTime, currentEstimateTime)
+
+	content := fmt.Sprintf("订单号: %s\n原官方预计时间: %s\n新官方预计时间: %s\n波动期间出现的时间: %s\n已稳定: %d 次检查 (%.1f 小时)\n确认时间: %s\n\n%s",
+		orderID,
+		confirmedEstimateTime,
+		currentEstimateTime,
+		strings.Join(seen, " → "),
+		stableChecks,
+		stableFor.Hours(),
+		time.Now().Format(utils.DateTimeFormat),
+		h.deliveryInfo.GetDetailedDeliveryInfo())
+	if isApproaching {
+		content += WarningPrefix + approachMsg
+	}
+
+	dedupKey := fmt.Sprintf("%s|%s|%s->%s", EventTimeChanged, orderID, confirmedEstimateTime, currentEstimateTime)
+	if err := h.sendNotification(EventTimeChanged, dedupKey, TitleTimeConfirmed, content); err != nil {
+		return fmt.Errorf("发送确认变更通知失败: %w", err)
+	}
+
+	h.updateLastNotificationTime()
+	return nil
+}
+
 // HandlePeriodicNotification 处理定期通知和临近提醒
 func (h *Handler) HandlePeriodicNotification(orderID, currentEstimateTime string, isApproaching bool, approachMsg string) error {
 	shouldNotifyPeriodic := h.shouldSendPeriodicNotification()
@@ -133,9 +213,10 @@ func (h *Handler) HandlePeriodicNotification(orderID, currentEstimateTime string
 	// 构建通知内容
 	content := h.buildPeriodicNotificationContent(orderID, currentEstimateTime, notifyReasons, isApproaching, approachMsg, shouldNotifyPeriodic)
 
-	// 发送通知
-	if err := h.sendNotification(title, content); err != nil {
-		return fmt.Errorf("发送通知失败: %v", err)
+	// 内容包含下次通知时间，以预计时间和通知原因作为去重键
+	dedupKey := fmt.Sprintf("%s|%s|%s|%s", EventPeriodic, orderID, currentEstimateTime, strings.Join(notifyReasons, ","))
+	if err := h.sendNotification(EventPeriodic, dedupKey, title, content); err != nil {
+		return fmt.Errorf("发送通知失败: %w", err)
 	}
 
 	h.updateLastNotificationTime()
@@ -218,34 +299,90 @@ func (h *Handler) SetLastNotificationTime(t time.Time) {
 }
 
 // sendNotification 发送通知
-func (h *Handler) sendNotification(title, content string) error {
+// dedupKey 为空时使用标题和内容作为去重键
+func (h *Handler) sendNotification(event, dedupKey, title, content string) error {
+	return h.send(event, dedupKey, title, content, false)
+}
+
+// send 发送通知，critical 为 true 时不受静音影响
+func (h *Handler) send(event, dedupKey, title, content string, critical bool) error {
 	if len(h.notifiers) == 0 {
 		log.Println("未配置任何通知器，跳过通知")
 		return nil
 	}
 
-	var errors []string
+	if mutedUntil := h.MutedUntil(); !mutedUntil.IsZero() && !critical {
+		log.Printf("通知已静音至 %s，跳过: %s", mutedUntil.Format(utils.DateTimeShort), title)
+		h.limiter.Suppress("静音期间")
+		return ErrSuppressed
+	}
+
+	if dedupKey == "" {
+		dedupKey = title + "\n" + content
+	}
+
+	if h.limiter.IsDuplicate(dedupKey) {
+		log.Printf("去重窗口内已发送过相同通知，跳过: %s", title)
+		return ErrSuppressed
+	}
+
+	if !h.limiter.AllowEvent(event) {
+		return ErrSuppressed
+	}
+
+	// 附带被抑制通知的摘要
+	if summary := h.limiter.PendingSummary(); summary != "" {
+		content += "\n\n" + summary
+	}
+
+	var sendErrors []string
 	successCount := 0
+	attempted := 0
 
 	for _, n := range h.notifiers {
-		if err := n.Send(title, content); err != nil {
+		if !h.limiter.AllowNotifier(notifier.ID(n), notifier.Name(n)) {
+			continue
+		}
+		attempted++
+
+		err := n.Send(title, content)
+		if h.OnSendResult != nil {
+			h.OnSendResult(n, title, err)
+		}
+		if err != nil {
 			log.Printf("通知发送失败: %v", err)
-			errors = append(errors, err.Error())
+			sendErrors = append(sendErrors, err.Error())
 		} else {
 			successCount++
 		}
 	}
 
+	if attempted == 0 {
+		return ErrSuppressed
+	}
+
 	if successCount == 0 {
-		return fmt.Errorf("所有通知器发送失败: %v", errors)
-	} else if len(errors) > 0 {
-		log.Printf("部分通知器发送失败 (%d/%d 成功): %v", successCount, len(h.notifiers), errors)
+		return fmt.Errorf("所有通知器发送失败: %v", sendErrors)
+	} else if len(sendErrors) > 0 {
+		log.Printf("部分通知器发送失败 (%d/%d 成功): %v", successCount, attempted, sendErrors)
 	}
 
+	h.limiter.MarkSent(dedupKey)
+	h.limiter.ClearPending()
 	return nil
 }
 
 // SendCustomNotification 发送自定义通知
 func (h *Handler) SendCustomNotification(title, content string) error {
-	return h.sendNotification(title, content)
+	return h.sendNotification(EventCustom, "", title, content)
+}
+
+// SendEventNotification 按指定事件类型和去重键发送通知，用于内容带有时间戳、无法按内容去重的通知
+func (h *Handler) SendEventNotification(event, dedupKey, title, content string) error {
+	return h.sendNotification(event, dedupKey, title, content)
+}
+
+// SendCriticalNotification 发送必须送达的告警（如 Cookie 失效），静音期间也会发送
+func (h *Handler) SendCriticalNotification(event, dedupKey, title, content string) error {
+	return h.send(event, dedupKey, title, content, true)
 }
@@ -0,0 +1,70 @@
+package notification
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"lixiang-monitor/delivery"
+	"lixiang-monitor/notifier"
+)
+
+// recordingNotifier 记录收到的通知标题
+type recordingNotifier struct {
+	titles []string
+}
+
+func (r *recordingNotifier) Send(title, content string) error {
+	r.titles = append(r.titles, title)
+	return nil
+}
+
+func newTestHandler(n notifier.Notifier) *Handler {
+	info := delivery.NewInfo(time.Now().AddDate(0, 0, -7), 4, 6)
+	h := NewHandler([]notifier.Notifier{n}, info, time.Hour, true, false)
+	h.UpdateLimits(time.Hour, 0, 0)
+	return h
+}
+
+func TestCriticalNotificationBypassesMute(t *testing.T) {
+	rec := &recordingNotifier{}
+	h := newTestHandler(rec)
+	h.Mute(time.Now().Add(time.Hour))
+
+	if err := h.SendCustomNotification("custom", "content"); !errors.Is(err, ErrSuppressed) {
+		t.Errorf("SendCustomNotification while muted = %v, want ErrSuppressed", err)
+	}
+	if err := h.SendCriticalNotification(EventCookie, "cookie_expired|1", "expired", "content"); err != nil {
+		t.Fatalf("SendCriticalNotification while muted = %v", err)
+	}
+	if len(rec.titles) != 1 || rec.titles[0] != "expired" {
+		t.Errorf("sent %v, want only the critical notification", rec.titles)
+	}
+
+	// 关键告警仍按去重键去重
+	if err := h.SendCriticalNotification(EventCookie, "cookie_expired|1", "expired", "other content"); !errors.Is(err, ErrSuppressed) {
+		t.Errorf("repeated critical notification = %v, want ErrSuppressed", err)
+	}
+}
+
+func TestPeriodicNotificationDedup(t *testing.T) {
+	rec := &recordingNotifier{}
+	h := newTestHandler(rec)
+
+	// 内容中的下次通知时间每次都不同，去重依赖预计时间和通知原因
+	h.SetLastNotificationTime(time.Now().Add(-2 * time.Hour))
+	if err := h.HandlePeriodicNotification("order", "4-6周", false, ""); err != nil {
+		t.Fatalf("first periodic notification: %v", err)
+	}
+	h.SetLastNotificationTime(time.Now().Add(-2 * time.Hour))
+	if err := h.HandlePeriodicNotification("order", "4-6周", false, ""); !errors.Is(err, ErrSuppressed) {
+		t.Errorf("repeated periodic notification = %v, want ErrSuppressed", err)
+	}
+	h.SetLastNotificationTime(time.Now().Add(-2 * time.Hour))
+	if err := h.HandlePeriodicNotification("order", "3-5周", false, ""); err != nil {
+		t.Errorf("periodic notification with a new estimate: %v", err)
+	}
+	if len(rec.titles) != 2 {
+		t.Errorf("sent %d notifications, want 2", len(rec.titles))
+	}
+}
@@ -0,0 +1,236 @@
+package notification
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 通知事件类型，用于按事件类型限流
+const (
+	EventFirstCheck  = "first_check"
+	EventTimeChanged = "time_changed"
+	EventPeriodic    = "periodic"
+	EventCookie      = "cookie"
+	EventCustom      = "custom"
+)
+
+// tokenBucket 令牌桶
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter 通知去重与限流器
+type Limiter struct {
+	mu sync.Mutex
+
+	dedupWindow time.Duration // 去重窗口，0 表示不去重
+	burst       int           // 令牌桶容量，0 表示不限流
+	perHour     float64       // 每小时补充的令牌数
+
+	recent     map[string]time.Time    // 去重键 -> 最近发送时间
+	buckets    map[string]*tokenBucket // 限流键 -> 令牌桶
+	suppressed map[string]int          // 抑制原因 -> 次数
+}
+
+// NewLimiter 创建通知去重与限流器
+func NewLimiter(dedupWindow time.Duration, burst int, perHour float64) *Limiter {
+	return &Limiter{
+		dedupWindow: dedupWindow,
+		burst:       burst,
+		perHour:     perHour,
+		recent:      make(map[string]time.Time),
+		buckets:     make(map[string]*tokenBucket),
+		suppressed:  make(map[string]int),
+	}
+}
+
+// UpdateConfig 更新去重窗口和限流参数
+func (l *Limiter) UpdateConfig(dedupWindow time.Duration, burst int, perHour float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if burst != l.burst || perHour != l.perHour {
+		// 限流参数变化后重新计算令牌
+		l.buckets = make(map[string]*tokenBucket)
+	}
+	l.dedupWindow = dedupWindow
+	l.burst = burst
+	l.perHour = perHour
+}
+
+// IsDuplicate 检查相同内容是否在去重窗口内已发送过
+func (l *Limiter) IsDuplicate(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.dedupWindow <= 0 || key == "" {
+		return false
+	}
+
+	now := time.Now()
+	for k, t := range l.recent {
+		if now.Sub(t) >= l.dedupWindow {
+			delete(l.recent, k)
+		}
+	}
+
+	if _, ok := l.recent[key]; ok {
+		l.suppressed["重复通知"]++
+		return true
+	}
+	return false
+}
+
+// MarkSent 记录内容已发送
+func (l *Limiter) MarkSent(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.dedupWindow <= 0 || key == "" {
+		return
+	}
+	l.recent[key] = time.Now()
+}
+
+// AllowEvent 按事件类型限流
+func (l *Limiter) AllowEvent(event string) bool {
+	return l.allow("event:"+event, fmt.Sprintf("事件限流(%s)", event))
+}
+
+// AllowNotifier 按通知器限流，id 为通知器的配置标识，name 用于抑制摘要
+func (l *Limiter) AllowNotifier(id, name string) bool {
+	return l.allow("notifier:"+id, fmt.Sprintf("通知器限流(%s)", name))
+}
+
+// allow 从令牌桶中取出一个令牌
+func (l *Limiter) allow(key, reason string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.burst <= 0 {
+		return true
+	}
+
+	now := time.Now()
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(l.burst), last: now}
+		l.buckets[key] = bucket
+	}
+
+	// 按时间补充令牌
+	bucket.tokens += now.Sub(bucket.last).Hours() * l.perHour
+	if bucket.tokens > float64(l.burst) {
+		bucket.tokens = float64(l.burst)
+	}
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		l.suppressed[reason]++
+		log.Printf("通知已被限流: %s", reason)
+		return false
+	}
+
+	bucket.tokens--
+	return true
+}
+
+// Suppress 记录一次因其他原因被抑制的通知
+func (l *Limiter) Suppress(reason string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.suppressed[reason]++
+}
+
+// PendingSummary 返回自上次成功发送以来被抑制的通知摘要，无抑制时返回空字符串
+func (l *Limiter) PendingSummary() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.suppressed) == 0 {
+		return ""
+	}
+
+	reasons := make([]string, 0, len(l.suppressed))
+	total := 0
+	for reason, count := range l.suppressed {
+		reasons = append(reasons, fmt.Sprintf("%s %d 条", reason, count))
+		total += count
+	}
+	sort.Strings(reasons)
+
+	return fmt.Sprintf("🔕 自上次通知以来已抑制 %d 条通知: %s", total, strings.Join(reasons, "、"))
+}
+
+// LimiterState 去重与限流器的可持久化状态，用于单次检查模式跨进程保留去重记录和令牌
+type LimiterState struct {
+	Recent     map[string]time.Time   `json:"recent,omitempty"`
+	Buckets    map[string]BucketState `json:"buckets,omitempty"`
+	Suppressed map[string]int         `json:"suppressed,omitempty"`
+}
+
+// BucketState 令牌桶状态
+type BucketState struct {
+	Tokens float64   `json:"tokens"`
+	Last   time.Time `json:"last"`
+}
+
+// State 导出当前状态
+func (l *Limiter) State() LimiterState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state := LimiterState{
+		Recent:     make(map[string]time.Time, len(l.recent)),
+		Buckets:    make(map[string]BucketState, len(l.buckets)),
+		Suppressed: make(map[string]int, len(l.suppressed)),
+	}
+	for k, t := range l.recent {
+		state.Recent[k] = t
+	}
+	for k, b := range l.buckets {
+		state.Buckets[k] = BucketState{Tokens: b.tokens, Last: b.last}
+	}
+	for k, n := range l.suppressed {
+		state.Suppressed[k] = n
+	}
+	return state
+}
+
+// Restore 恢复之前导出的状态，去重窗口和限流参数保持当前配置
+func (l *Limiter) Restore(state LimiterState) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.recent = make(map[string]time.Time, len(state.Recent))
+	for k, t := range state.Recent {
+		l.recent[k] = t
+	}
+	l.buckets = make(map[string]*tokenBucket, len(state.Buckets))
+	for k, b := range state.Buckets {
+		tokens := b.Tokens
+		if l.burst > 0 && tokens > float64(l.burst) {
+			// 限流容量调小后不沿用多出的令牌
+			tokens = float64(l.burst)
+		}
+		l.buckets[k] = &tokenBucket{tokens: tokens, last: b.Last}
+	}
+	l.suppressed = make(map[string]int, len(state.Suppressed))
+	for k, n := range state.Suppressed {
+		l.suppressed[k] = n
+	}
+}
+
+// ClearPending 清空抑制计数
+func (l *Limiter) ClearPending() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.suppressed = make(map[string]int)
+}
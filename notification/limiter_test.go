@@ -0,0 +1,147 @@
+package notification
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"lixiang-monitor/notifier"
+)
+
+func TestLimiterDedup(t *testing.T) {
+	l := NewLimiter(time.Hour, 0, 0)
+	if l.IsDuplicate("content") {
+		t.Fatal("unsent content reported as duplicate")
+	}
+	l.MarkSent("content")
+	if !l.IsDuplicate("content") {
+		t.Error("sent content not reported as duplicate")
+	}
+	if l.IsDuplicate("other content") || l.IsDuplicate("") {
+		t.Error("different or empty content reported as duplicate")
+	}
+
+	// 超出去重窗口的记录失效
+	l.recent["content"] = time.Now().Add(-time.Hour)
+	if l.IsDuplicate("content") {
+		t.Error("content outside the dedup window reported as duplicate")
+	}
+
+	disabled := NewLimiter(0, 0, 0)
+	disabled.MarkSent("content")
+	if disabled.IsDuplicate("content") {
+		t.Error("duplicate reported with dedup disabled")
+	}
+}
+
+func TestLimiterTokenBucket(t *testing.T) {
+	l := NewLimiter(0, 2, 1)
+	for i := 0; i < 2; i++ {
+		if !l.AllowEvent(EventPeriodic) {
+			t.Fatalf("event %d rejected within burst", i+1)
+		}
+	}
+	if l.AllowEvent(EventPeriodic) {
+		t.Fatal("event allowed after burst was used up")
+	}
+
+	// 事件和通知器各自独立计数
+	if !l.AllowEvent(EventTimeChanged) || !l.AllowNotifier("bark:1", "Bark") {
+		t.Error("separate bucket limited by another key")
+	}
+
+	// 一小时补充 1 个令牌
+	l.buckets["event:"+EventPeriodic] = &tokenBucket{tokens: 0, last: time.Now().Add(-time.Hour)}
+	if !l.AllowEvent(EventPeriodic) {
+		t.Error("token not refilled after an hour")
+	}
+	if l.AllowEvent(EventPeriodic) {
+		t.Error("refilled more than one token in an hour")
+	}
+
+	if summary := l.PendingSummary(); !strings.Contains(summary, "事件限流(periodic) 2 条") {
+		t.Errorf("PendingSummary() = %q, want the periodic count", summary)
+	}
+	l.ClearPending()
+	if summary := l.PendingSummary(); summary != "" {
+		t.Errorf("PendingSummary() after ClearPending = %q", summary)
+	}
+
+	unlimited := NewLimiter(0, 0, 0)
+	for i := 0; i < 10; i++ {
+		if !unlimited.AllowEvent(EventPeriodic) {
+			t.Fatal("event rejected with rate limiting disabled")
+		}
+	}
+}
+
+func TestLimiterNotifierBucketsByID(t *testing.T) {
+	l := NewLimiter(0, 1, 0)
+	first := &notifier.BarkNotifier{ServerURL: "https://api.day.app/key-a"}
+	second := &notifier.BarkNotifier{ServerURL: "https://api.day.app/key-b"}
+	if notifier.ID(first) == notifier.ID(second) {
+		t.Fatalf("two Bark notifiers share ID %q", notifier.ID(first))
+	}
+
+	if !l.AllowNotifier(notifier.ID(first), notifier.Name(first)) {
+		t.Fatal("first notifier rejected")
+	}
+	// 同类型的第二个通知器使用独立的令牌桶
+	if !l.AllowNotifier(notifier.ID(second), notifier.Name(second)) {
+		t.Error("second notifier of the same kind limited by the first")
+	}
+	if l.AllowNotifier(notifier.ID(first), notifier.Name(first)) {
+		t.Error("first notifier allowed after its burst was used up")
+	}
+}
+
+func TestLimiterStateRestore(t *testing.T) {
+	l := NewLimiter(time.Hour, 1, 0)
+	l.MarkSent("same content")
+	if !l.AllowEvent(EventTimeChanged) {
+		t.Fatal("first event rejected")
+	}
+	l.Suppress("静音期间")
+
+	// 模拟跨进程：序列化后恢复到新的限流器
+	data, err := json.Marshal(l.State())
+	if err != nil {
+		t.Fatalf("marshal state: %v", err)
+	}
+	var state LimiterState
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("unmarshal state: %v", err)
+	}
+	restored := NewLimiter(time.Hour, 1, 0)
+	restored.Restore(state)
+
+	if !restored.IsDuplicate("same content") {
+		t.Error("dedup record lost after restore")
+	}
+	if restored.AllowEvent(EventTimeChanged) {
+		t.Error("token bucket refilled after restore")
+	}
+	if restored.PendingSummary() == "" {
+		t.Error("suppressed counts lost after restore")
+	}
+}
+
+func TestLimiterRestoreCapsTokens(t *testing.T) {
+	l := NewLimiter(0, 5, 0)
+	l.Restore(LimiterState{Buckets: map[string]BucketState{
+		"event:" + EventPeriodic: {Tokens: 5, Last: time.Now()},
+	}})
+
+	restored := NewLimiter(0, 2, 0)
+	restored.Restore(l.State())
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if restored.AllowEvent(EventPeriodic) {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("allowed %d events after restoring into a smaller bucket, want 2", allowed)
+	}
+}
@@ -1,6 +1,54 @@
 package notifier
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
 // Notifier 通知接口
 type Notifier interface {
 	Send(title, content string) error
 }
+
+// Name 返回通知器的显示名称
+func Name(n Notifier) string {
+	switch n.(type) {
+	case *WeChatWebhookNotifier:
+		return "微信群机器人"
+	case *ServerChanNotifier:
+		return "ServerChan"
+	case *BarkNotifier:
+		return "Bark"
+	default:
+		return "未知通知器"
+	}
+}
+
+// Kind 返回通知器类型标识，用于指标标签等需要 ASCII 名称的场景
+func Kind(n Notifier) string {
+	switch n.(type) {
+	case *WeChatWebhookNotifier:
+		return "wechat"
+	case *ServerChanNotifier:
+		return "serverchan"
+	case *BarkNotifier:
+		return "bark"
+	default:
+		return "unknown"
+	}
+}
+
+// ID 返回通知器的配置标识（类型加目标地址的摘要），同类型的多个通知器互不相同，且不暴露密钥
+func ID(n Notifier) string {
+	var target string
+	switch v := n.(type) {
+	case *WeChatWebhookNotifier:
+		target = v.WebhookURL
+	case *ServerChanNotifier:
+		target = v.BaseURL + "|" + v.SendKey
+	case *BarkNotifier:
+		target = v.ServerURL
+	}
+	sum := sha256.Sum256([]byte(target))
+	return Kind(n) + ":" + hex.EncodeToString(sum[:4])
+}
@@ -88,5 +88,5 @@ echo "常用命令:"
 echo "🚀 启动: ./start.sh"
 echo "🛑 停止: ./stop.sh"
 echo "📊 状态: ./status.sh"
-echo "🧪 测试: ./test-notification.sh"
+echo "🧪 测试: ./lixiang-monitor notify-test"
 echo "📋 日志: tail -f $LOG_FILE"
\ No newline at end of file
@@ -18,13 +18,13 @@ fi
 echo "📝 找到程序进程 (PID: $PID)"
 kill $PID
 
-# 等待程序停止
-for i in {1..5}; do
+# 等待程序停止（程序会等待正在进行的检查完成，最多 30 秒）
+for i in {1..35}; do
     if ! kill -0 $PID 2>/dev/null; then
         echo "✅ 程序已停止"
         exit 0
     fi
-    echo "⏳ 等待程序停止... ($i/5)"
+    echo "⏳ 等待程序停止... ($i/35)"
     sleep 1
 done
 
@@ -36,7 +36,7 @@ echo "5. 简化 main.go"
 echo ""
 echo -e "${YELLOW}每一步重构后都要运行测试:${NC}"
 echo "  go build -o lixiang-monitor"
-echo "  ./scripts/test/test-notification.sh"
+echo "  ./lixiang-monitor notify-test"
 echo ""
 echo -e "${BLUE}详细重构方案请查看:${NC} REFACTORING_PLAN.md"
 echo ""
@@ -37,7 +37,6 @@ echo "  - 技术文档: $(ls -1 docs/technical/*.md 2>/dev/null | wc -l | tr -d
 
 echo ""
 echo "🔧 脚本文件:"
-echo "  - 测试脚本: $(ls -1 scripts/test/* 2>/dev/null | wc -l | tr -d ' ') 个"
 echo "  - 部署脚本: $(ls -1 scripts/deploy/* 2>/dev/null | wc -l | tr -d ' ') 个"
 
 echo ""
@@ -57,7 +56,7 @@ echo "📋 查看重组总结:"
 echo "  cat PROJECT_REORGANIZATION.md"
 echo ""
 echo "🧪 运行测试:"
-echo "  cd scripts/test && ./test-notification.sh"
+echo "  ./lixiang-monitor notify-test"
 echo ""
 echo "🚀 部署服务:"
 echo "  cd scripts/deploy && ./build.sh && ./start.sh"
@@ -34,3 +34,34 @@ func ParseLockOrderTime(timeStr string) (time.Time, error) {
 
 	return time.Time{}, fmt.Errorf("无法解析时间格式: %s", timeStr)
 }
+
+// rangeTimeFormats 查询时间范围支持的格式（RFC3339 以外）
+var rangeTimeFormats = []string{
+	DateTimeFormat,
+	"2006-01-02T15:04:05",
+	DateTimeShort,
+	DateFormat,
+}
+
+// ParseRangeTime 解析查询时间范围的起止时间，为空时返回零值
+// 支持 RFC3339 以及不带时区的日期时间（按 loc 时区解析）；
+// endOfDay 为 true 且只给出日期时返回次日零点，使作为结束时间的 2025-10-20 包含当天
+func ParseRangeTime(value string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(loc), nil
+	}
+	for _, format := range rangeTimeFormats {
+		t, err := time.ParseInLocation(format, value, loc)
+		if err != nil {
+			continue
+		}
+		if endOfDay && format == DateFormat {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("无法解析时间 %q，支持 RFC3339 或 %s 格式", value, DateTimeFormat)
+}
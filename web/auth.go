@@ -0,0 +1,293 @@
+package web
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sessionCookieName 登录会话 Cookie 名称
+const sessionCookieName = "lixiang_monitor_session"
+
+// AuthConfig Web 访问认证配置，各项均为空时不启用认证
+type AuthConfig struct {
+	Username    string        // 用户名，与 Password 同时设置时启用 HTTP Basic 认证和登录页面
+	Password    string        // 密码
+	BearerToken string        // 静态访问令牌，用于 API 调用（Authorization: Bearer <token>）
+	SessionTTL  time.Duration // 登录会话有效期
+
+	// CalendarToken 日历订阅令牌，只读且只能访问 /calendar.ics（通过 ?token= 传递）
+	// 日历应用无法设置请求头，订阅地址又容易被同步或分享出去，因此不接受 BearerToken 作为查询参数
+	CalendarToken string
+}
+
+// Enabled 是否启用认证
+func (c AuthConfig) Enabled() bool {
+	return c.passwordEnabled() || c.BearerToken != ""
+}
+
+// passwordEnabled 是否启用用户名密码认证
+func (c AuthConfig) passwordEnabled() bool {
+	return c.Username != "" && c.Password != ""
+}
+
+// sessionStore 内存中的登录会话，服务重启后需要重新登录
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]time.Time // 会话 ID -> 过期时间
+}
+
+// create 创建新会话并返回会话 ID
+func (st *sessionStore) create(ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(b)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := time.Now()
+	for sid, expires := range st.sessions {
+		if now.After(expires) {
+			delete(st.sessions, sid)
+		}
+	}
+	st.sessions[id] = now.Add(ttl)
+	return id, nil
+}
+
+// valid 检查会话是否有效
+func (st *sessionStore) valid(id string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	expires, ok := st.sessions[id]
+	if !ok {
+		return false
+	}
+	if time.Now().After(expires) {
+		delete(st.sessions, id)
+		return false
+	}
+	return true
+}
+
+// remove 删除会话
+func (st *sessionStore) remove(id string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	delete(st.sessions, id)
+}
+
+// clear 删除所有会话
+func (st *sessionStore) clear() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.sessions = make(map[string]time.Time)
+}
+
+// SetAuth 设置 Web 访问认证，可在运行中调用以热更新
+// 用户名或密码变化时，已有的登录会话全部失效
+func (s *Server) SetAuth(auth AuthConfig) {
+	if auth.SessionTTL <= 0 {
+		auth.SessionTTL = 24 * time.Hour
+	}
+
+	s.authMu.Lock()
+	defer s.authMu.Unlock()
+
+	if auth.Username != s.auth.Username || auth.Password != s.auth.Password {
+		s.sessions.clear()
+	}
+	s.auth = auth
+}
+
+// getAuth 返回当前的认证配置
+func (s *Server) getAuth() AuthConfig {
+	s.authMu.RLock()
+	defer s.authMu.RUnlock()
+
+	return s.auth
+}
+
+// authMiddleware 认证中间件
+// 依次接受登录会话 Cookie、HTTP Basic 认证和 Bearer 令牌；
+// 未认证时页面请求跳转到登录页，API 请求返回 401
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := s.getAuth()
+		if !auth.Enabled() || s.authExempt(r.URL.Path) || s.authenticated(r, auth) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if s.isPageRequest(r) && auth.passwordEnabled() {
+			target := s.route("/login") + "?next=" + url.QueryEscape(r.URL.RequestURI())
+			http.Redirect(w, r, target, http.StatusFound)
+			return
+		}
+
+		if auth.passwordEnabled() {
+			w.Header().Set("WWW-Authenticate", `Basic realm="lixiang-monitor", charset="UTF-8"`)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		s.sendJSONError(w, "未认证", http.StatusUnauthorized)
+	})
+}
+
+// authExempt 不需要认证的路径：登录/退出页面、健康检查、有独立口令的 Cookie 更新接口和外部注册的回调
+func (s *Server) authExempt(path string) bool {
+	switch path {
+	case s.route("/login"), s.route("/logout"), s.route("/healthz"), s.route("/readyz"),
+		s.route("/cookie"), s.route("/api/cookie"):
+		return true
+	}
+	for _, r := range s.routes {
+		if path == s.route(r.path) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticated 检查请求是否已认证
+func (s *Server) authenticated(r *http.Request, auth AuthConfig) bool {
+	if c, err := r.Cookie(sessionCookieName); err == nil && s.sessions.valid(c.Value) {
+		return true
+	}
+
+	if auth.passwordEnabled() {
+		if username, password, ok := r.BasicAuth(); ok && s.checkCredentials(auth, username, password) {
+			return true
+		}
+	}
+
+	if auth.BearerToken != "" {
+		header := r.Header.Get("Authorization")
+		if strings.HasPrefix(header, "Bearer ") {
+			token := strings.TrimPrefix(header, "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(auth.BearerToken)) == 1 {
+				return true
+			}
+		}
+	}
+
+	if auth.CalendarToken != "" && r.Method == http.MethodGet && r.URL.Path == s.route("/calendar.ics") {
+		token := r.URL.Query().Get("token")
+		if token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(auth.CalendarToken)) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkCredentials 以固定时间比较用户名和密码
+func (s *Server) checkCredentials(auth AuthConfig, username, password string) bool {
+	userOK := subtle.ConstantTimeCompare([]byte(username), []byte(auth.Username)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(password), []byte(auth.Password)) == 1
+	return userOK && passOK
+}
+
+// isPageRequest 判断是否为浏览器页面请求（而非 API 调用）
+func (s *Server) isPageRequest(r *http.Request) bool {
+	if r.Method != http.MethodGet || strings.HasPrefix(r.URL.Path, s.route("/api/")) {
+		return false
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// handleLogin 处理登录页面和登录请求
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	auth := s.getAuth()
+	if !auth.passwordEnabled() {
+		http.NotFound(w, r)
+		return
+	}
+
+	next := s.safeRedirect(r.FormValue("next"))
+	errMsg := ""
+
+	if r.Method == http.MethodPost {
+		if s.checkCredentials(auth, r.PostFormValue("username"), r.PostFormValue("password")) {
+			id, err := s.sessions.create(auth.SessionTTL)
+			if err != nil {
+				http.Error(w, "创建会话失败", http.StatusInternalServerError)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{
+				Name:     sessionCookieName,
+				Value:    id,
+				Path:     s.cookiePath(),
+				MaxAge:   int(auth.SessionTTL.Seconds()),
+				HttpOnly: true,
+				Secure:   r.TLS != nil,
+				SameSite: http.SameSiteLaxMode,
+			})
+			log.Printf("[Web] 用户登录成功: %s", r.RemoteAddr)
+			http.Redirect(w, r, next, http.StatusSeeOther)
+			return
+		}
+
+		// 登录失败时稍作延迟，降低暴力破解的速度
+		log.Printf("[Web] 登录失败: %s", r.RemoteAddr)
+		time.Sleep(time.Second)
+		w.WriteHeader(http.StatusUnauthorized)
+		errMsg = "用户名或密码错误"
+	}
+
+	data := map[string]interface{}{
+		"Title":    "登录 - 理想汽车订单监控",
+		"BasePath": s.basePath,
+		"Next":     next,
+		"Error":    errMsg,
+	}
+	if err := s.templates.ExecuteTemplate(w, "login.html", data); err != nil {
+		log.Printf("[Web] 渲染模板失败: %v", err)
+	}
+}
+
+// handleLogout 退出登录
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if c, err := r.Cookie(sessionCookieName); err == nil {
+		s.sessions.remove(c.Value)
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     s.cookiePath(),
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+	http.Redirect(w, r, s.route("/login"), http.StatusSeeOther)
+}
+
+// safeRedirect 只允许跳转到本站 basePath 下的路径，防止开放重定向
+func (s *Server) safeRedirect(next string) string {
+	home := s.route("/")
+	if next == "" || !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return home
+	}
+	if s.basePath != "" && next != s.basePath && !strings.HasPrefix(next, s.basePath+"/") {
+		return home
+	}
+	return next
+}
+
+// cookiePath 会话 Cookie 的作用路径
+func (s *Server) cookiePath() string {
+	if s.basePath == "" {
+		return "/"
+	}
+	return s.basePath
+}
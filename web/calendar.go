@@ -0,0 +1,167 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"lixiang-monitor/delivery"
+	"lixiang-monitor/utils"
+)
+
+// calendarRefreshInterval 建议日历客户端刷新订阅的间隔
+const calendarRefreshInterval = "PT1H"
+
+// calendarEvent 日历中的一个全天事件
+type calendarEvent struct {
+	uid         string
+	summary     string
+	description string
+	start, end  time.Time // 起止日期（均包含）
+	modified    time.Time
+}
+
+// handleCalendar 以 iCalendar 格式发布交付窗口和官方预计交付时间，供手机日历订阅
+// 事件 UID 固定，交付窗口或官方预计时间变化后，客户端下次刷新时会更新对应事件
+func (s *Server) handleCalendar(w http.ResponseWriter, r *http.Request) {
+	var events []calendarEvent
+
+	if s.windowProvider != nil {
+		if minDate, maxDate, ok := s.windowProvider.DeliveryWindow(); ok {
+			events = append(events, calendarEvent{
+				uid:         fmt.Sprintf("window-%s@lixiang-monitor", s.orderID),
+				summary:     "🚗 理想汽车预计交付窗口",
+				description: fmt.Sprintf("根据锁单时间推算的预计交付窗口: %s ~ %s", minDate.Format(utils.DateFormat), maxDate.Format(utils.DateFormat)),
+				start:       minDate,
+				end:         maxDate,
+			})
+		}
+	}
+
+	timeline, err := s.database.GetEstimateTimeline(s.orderID)
+	if err != nil {
+		log.Printf("[Web] 查询预计时间走势失败: %v", err)
+		http.Error(w, "查询预计时间失败", http.StatusInternalServerError)
+		return
+	}
+	if len(timeline) > 0 {
+		// 找到当前官方预计时间首次出现的检查，相对时间（如 "6-8周"）以该时间为起点换算，
+		// 使事件日期在预计时间变化前保持不变
+		current := timeline[len(timeline)-1].EstimateTime
+		since := timeline[len(timeline)-1].CheckTime
+		for i := len(timeline) - 1; i >= 0 && timeline[i].EstimateTime == current; i-- {
+			since = timeline[i].CheckTime
+		}
+
+		if minDate, maxDate, ok := delivery.ParseEstimate(current, since.Local()); ok {
+			events = append(events, calendarEvent{
+				uid:     fmt.Sprintf("estimate-%s@lixiang-monitor", s.orderID),
+				summary: "📦 官方预计交付: " + current,
+				description: fmt.Sprintf("理想汽车官方预计交付时间: %s\n自 %s 起",
+					current, since.Local().Format(utils.DateTimeFormat)),
+				start:    minDate,
+				end:      maxDate,
+				modified: since,
+			})
+		} else {
+			// 无法换算为日期（如"待定"）时不能生成全天事件，把官方预计时间写进交付窗口事件的说明里
+			log.Printf("[Web] 官方预计时间无法解析为日期，日历中不生成对应事件: %s", current)
+			if len(events) > 0 {
+				events[0].description += "\n官方预计交付时间: " + current + "（无法换算为日期）"
+			}
+		}
+	}
+
+	body := renderCalendar(s.orderID, events, time.Now())
+
+	// ETag 只取决于事件内容，内容不变时客户端可以用 If-None-Match 避免重复下载
+	sum := sha256.Sum256([]byte(stripDTStamp(body)))
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="lixiang-delivery.ics"`)
+	w.Write([]byte(body))
+}
+
+// renderCalendar 生成 iCalendar 文本（RFC 5545），全天事件的 DTEND 为结束日期的次日
+func renderCalendar(orderID string, events []calendarEvent, now time.Time) string {
+	var b strings.Builder
+	line := func(content string) {
+		b.WriteString(foldLine(content))
+		b.WriteString("\r\n")
+	}
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//lixiang-monitor//Delivery Calendar//ZH")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + escapeText("理想汽车交付 "+orderID))
+	line("REFRESH-INTERVAL;VALUE=DURATION:" + calendarRefreshInterval)
+	line("X-PUBLISHED-TTL:" + calendarRefreshInterval)
+	for _, event := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + event.uid)
+		line("DTSTAMP:" + stamp)
+		if !event.modified.IsZero() {
+			line("LAST-MODIFIED:" + event.modified.UTC().Format("20060102T150405Z"))
+		}
+		line("DTSTART;VALUE=DATE:" + event.start.Format("20060102"))
+		line("DTEND;VALUE=DATE:" + event.end.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY:" + escapeText(event.summary))
+		line("DESCRIPTION:" + escapeText(event.description))
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return b.String()
+}
+
+// stripDTStamp 去掉每次请求都会变化的 DTSTAMP 行，用于计算 ETag
+func stripDTStamp(body string) string {
+	lines := strings.Split(body, "\r\n")
+	kept := lines[:0]
+	for _, l := range lines {
+		if !strings.HasPrefix(l, "DTSTAMP:") {
+			kept = append(kept, l)
+		}
+	}
+	return strings.Join(kept, "\r\n")
+}
+
+// escapeText 转义 iCalendar TEXT 值中的特殊字符
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// foldLine 按 RFC 5545 将超过 75 字节的行折叠，不拆开 UTF-8 字符
+func foldLine(s string) string {
+	const limit = 75
+	if len(s) <= limit {
+		return s
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1 // 续行开头的空格
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
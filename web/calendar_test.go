@@ -0,0 +1,164 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"lixiang-monitor/db"
+)
+
+func TestFoldLine(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"short", "SUMMARY:short"},
+		{"exactly 75 bytes", "DESCRIPTION:" + strings.Repeat("a", 63)},
+		{"long ascii", "DESCRIPTION:" + strings.Repeat("a", 200)},
+		{"multibyte", "SUMMARY:" + strings.Repeat("理想汽车交付", 10)},
+		{"mixed", "DESCRIPTION:a" + strings.Repeat("预计", 40)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folded := foldLine(tt.in)
+			lines := strings.Split(folded, "\r\n")
+			for i, line := range lines {
+				if len(line) > 75 {
+					t.Errorf("line %d is %d bytes: %q", i, len(line), line)
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line %d splits a UTF-8 character: %q", i, line)
+				}
+				if i > 0 && !strings.HasPrefix(line, " ") {
+					t.Errorf("continuation line %d does not start with a space: %q", i, line)
+				}
+			}
+			if len(tt.in) <= 75 && len(lines) != 1 {
+				t.Errorf("line of %d bytes was folded", len(tt.in))
+			}
+			if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != tt.in {
+				t.Errorf("unfolded = %q, want %q", unfolded, tt.in)
+			}
+		})
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"a,b;c", `a\,b\;c`},
+		{`back\slash`, `back\\slash`},
+		{"line1\nline2", `line1\nline2`},
+		{"line1\r\nline2", `line1\nline2`},
+		{`\n,`, `\\n\,`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeText(tt.in); got != tt.want {
+			t.Errorf("escapeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// fixedWindow 固定的交付窗口
+type fixedWindow struct{}
+
+func (fixedWindow) DeliveryWindow() (time.Time, time.Time, bool) {
+	return time.Date(2025, 11, 1, 0, 0, 0, 0, time.Local), time.Date(2025, 11, 30, 0, 0, 0, 0, time.Local), true
+}
+
+// newTestServer 创建使用临时数据库的服务器，estimate 不为空时写入一条检查记录
+func newTestServer(t *testing.T, estimate string) *Server {
+	t.Helper()
+
+	database, err := db.New(filepath.Join(t.TempDir(), "monitor.db"))
+	if err != nil {
+		t.Fatalf("db.New() error = %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	if estimate != "" {
+		now := time.Now()
+		err := database.SaveDeliveryRecord(&db.DeliveryRecord{
+			OrderID:       "order-1",
+			EstimateTime:  estimate,
+			LockOrderTime: now,
+			CheckTime:     now,
+			CreatedAt:     now,
+		})
+		if err != nil {
+			t.Fatalf("SaveDeliveryRecord() error = %v", err)
+		}
+	}
+
+	s, err := NewServer(database, "order-1", 0, "")
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	return s
+}
+
+func TestCalendarUnparseableEstimate(t *testing.T) {
+	s := newTestServer(t, "待定")
+	s.SetDeliveryWindow(fixedWindow{})
+
+	rec := httptest.NewRecorder()
+	s.handleCalendar(rec, httptest.NewRequest(http.MethodGet, "/calendar.ics", nil))
+
+	body := strings.ReplaceAll(rec.Body.String(), "\r\n ", "")
+	if strings.Contains(body, "UID:estimate-") {
+		t.Error("emitted a dated event for an unparseable estimate")
+	}
+	if !strings.Contains(body, "UID:window-order-1@lixiang-monitor") {
+		t.Fatal("window event missing")
+	}
+	if !strings.Contains(body, `官方预计交付时间: 待定`) {
+		t.Errorf("unparseable estimate not mentioned in the calendar:\n%s", body)
+	}
+}
+
+func TestCalendarTokenAuth(t *testing.T) {
+	s := newTestServer(t, "")
+	s.SetAuth(AuthConfig{BearerToken: "master", CalendarToken: "calendar"})
+	handler := s.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		bearer string
+		code   int
+	}{
+		{"calendar token on calendar", http.MethodGet, "/calendar.ics?token=calendar", "", http.StatusOK},
+		{"master token in calendar url", http.MethodGet, "/calendar.ics?token=master", "", http.StatusUnauthorized},
+		{"wrong calendar token", http.MethodGet, "/calendar.ics?token=wrong", "", http.StatusUnauthorized},
+		{"calendar token on api", http.MethodGet, "/api/records?token=calendar", "", http.StatusUnauthorized},
+		{"calendar token as bearer", http.MethodGet, "/api/records", "calendar", http.StatusUnauthorized},
+		{"calendar token with post", http.MethodPost, "/calendar.ics?token=calendar", "", http.StatusUnauthorized},
+		{"master bearer on calendar", http.MethodGet, "/calendar.ics", "master", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.code {
+				t.Errorf("status = %d, want %d", rec.Code, tt.code)
+			}
+		})
+	}
+}
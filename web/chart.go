@@ -0,0 +1,77 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"lixiang-monitor/delivery"
+)
+
+// DeliveryWindowProvider 提供基于锁单时间推算的预计交付窗口
+type DeliveryWindowProvider interface {
+	// DeliveryWindow 返回预计交付窗口，未配置锁单时间时 ok 为 false
+	DeliveryWindow() (minDate, maxDate time.Time, ok bool)
+}
+
+// ChartResponse 预计时间走势图数据，时间均为 Unix 秒
+type ChartResponse struct {
+	Now           int64           `json:"now"`
+	LockOrderTime int64           `json:"lock_order_time,omitempty"`
+	Window        []int64         `json:"window,omitempty"` // [最早, 最晚]，基于锁单时间推算
+	Points        [][]interface{} `json:"points"`           // 预计时间变化节点: [检查时间, 最早日期, 最晚日期, 原始文本]，无法解析时日期为 0
+	LastCheck     int64           `json:"last_check,omitempty"`
+	Unparsed      []string        `json:"unparsed,omitempty"` // 无法解析为日期的预计时间文本
+}
+
+// SetDeliveryWindow 设置走势图中预计交付窗口的来源
+func (s *Server) SetDeliveryWindow(provider DeliveryWindowProvider) {
+	s.windowProvider = provider
+}
+
+// handleChart 返回预计时间走势图数据
+func (s *Server) handleChart(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	records, err := s.database.GetEstimateTimeline(s.orderID)
+	if err != nil {
+		log.Printf("[Web] 查询预计时间走势失败: %v", err)
+		s.sendJSONError(w, "查询预计时间走势失败", http.StatusInternalServerError)
+		return
+	}
+
+	response := ChartResponse{
+		Now:    time.Now().Unix(),
+		Points: make([][]interface{}, 0, len(records)),
+	}
+
+	if s.windowProvider != nil {
+		if minDate, maxDate, ok := s.windowProvider.DeliveryWindow(); ok {
+			response.Window = []int64{minDate.Unix(), maxDate.Unix()}
+		}
+	}
+
+	unparsed := make(map[string]bool)
+	for i, record := range records {
+		// 最新一条记录与上一个节点相同时只用于延长阶梯线
+		if i == len(records)-1 {
+			response.LastCheck = record.CheckTime.Unix()
+			response.LockOrderTime = record.LockOrderTime.Unix()
+			if i > 0 && record.EstimateTime == records[i-1].EstimateTime {
+				break
+			}
+		}
+
+		var minUnix, maxUnix int64
+		if minDate, maxDate, ok := delivery.ParseEstimate(record.EstimateTime, record.CheckTime.Local()); ok {
+			minUnix, maxUnix = minDate.Unix(), maxDate.Unix()
+		} else if !unparsed[record.EstimateTime] {
+			unparsed[record.EstimateTime] = true
+			response.Unparsed = append(response.Unparsed, record.EstimateTime)
+		}
+		response.Points = append(response.Points, []interface{}{record.CheckTime.Unix(), minUnix, maxUnix, record.EstimateTime})
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
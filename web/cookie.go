@@ -0,0 +1,225 @@
+package web
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cookieUpdateTimeout Cookie 更新请求的写超时，需覆盖新 Cookie 的验证请求（最长 30 秒）
+const cookieUpdateTimeout = 60 * time.Second
+
+// 口令错误锁定：同一 IP 连续输错 cookieTokenMaxFailures 次后锁定 cookieTokenLockout
+const (
+	cookieTokenMaxFailures = 5
+	cookieTokenLockout     = 15 * time.Minute
+)
+
+// CookieUpdateRequest Cookie 更新请求
+type CookieUpdateRequest struct {
+	Token   string `json:"token"`
+	Cookies string `json:"cookies"`
+}
+
+// CookieUpdateResponse Cookie 更新响应
+type CookieUpdateResponse struct {
+	Success      bool   `json:"success"`
+	Message      string `json:"message"`
+	EstimateTime string `json:"estimate_time,omitempty"`
+	UpdatedAt    string `json:"updated_at,omitempty"`
+}
+
+// handleCookiePage 处理 Cookie 更新页面
+func (s *Server) handleCookiePage(w http.ResponseWriter, r *http.Request) {
+	data := map[string]interface{}{
+		"OrderID":  s.orderID,
+		"Title":    "更新理想汽车 Cookie",
+		"BasePath": s.basePath,
+	}
+
+	if err := s.templates.ExecuteTemplate(w, "cookie.html", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("[Web] 渲染模板失败: %v", err)
+	}
+}
+
+// handleCookieUpdate 处理 Cookie 更新请求
+func (s *Server) handleCookieUpdate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		s.sendJSONError(w, "仅支持 POST 请求", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ip := clientIP(r)
+	if until := s.cookieFailures.lockedUntil(ip, time.Now()); !until.IsZero() {
+		w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(until).Seconds())+1))
+		s.sendJSONError(w, "口令错误次数过多，请稍后再试", http.StatusTooManyRequests)
+		return
+	}
+
+	// 验证新 Cookie 需要实时请求订单接口，不受服务器默认的写超时限制
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(cookieUpdateTimeout)); err != nil {
+		log.Printf("[Web] 设置 Cookie 更新写超时失败: %v", err)
+	}
+
+	req, err := parseCookieUpdateRequest(w, r)
+	if err != nil {
+		s.sendJSONError(w, "请求格式错误", http.StatusBadRequest)
+		return
+	}
+
+	if !s.checkCookieToken(r, req.Token) {
+		if s.cookieFailures.fail(ip, time.Now()) {
+			log.Printf("[Web] ⚠️  Cookie 更新口令连续错误，已锁定 %s: %s", cookieTokenLockout, ip)
+		} else {
+			log.Printf("[Web] Cookie 更新口令错误: %s", r.RemoteAddr)
+		}
+		s.sendJSONError(w, "口令错误", http.StatusUnauthorized)
+		return
+	}
+	s.cookieFailures.reset(ip)
+
+	cookies := strings.TrimSpace(req.Cookies)
+	if cookies == "" {
+		s.sendJSONError(w, "Cookie 不能为空", http.StatusBadRequest)
+		return
+	}
+
+	estimateTime, err := s.cookieUpdater.TestAndUpdateCookie(cookies)
+	if err != nil {
+		log.Printf("[Web] 新 Cookie 验证失败: %v", err)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(CookieUpdateResponse{
+			Success: false,
+			Message: "新 Cookie 验证失败，未保存: " + err.Error(),
+		})
+		return
+	}
+
+	log.Println("[Web] Cookie 已通过 Web 更新")
+	json.NewEncoder(w).Encode(CookieUpdateResponse{
+		Success:      true,
+		Message:      "Cookie 验证通过并已保存",
+		EstimateTime: estimateTime,
+		UpdatedAt:    time.Now().Format("2006-01-02 15:04:05"),
+	})
+}
+
+// parseCookieUpdateRequest 解析 JSON 或表单格式的请求
+func parseCookieUpdateRequest(w http.ResponseWriter, r *http.Request) (*CookieUpdateRequest, error) {
+	// HAR 文件可能较大
+	r.Body = http.MaxBytesReader(w, r.Body, 32<<20)
+
+	req := &CookieUpdateRequest{}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			return nil, err
+		}
+		return req, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	req.Token = r.PostForm.Get("token")
+	req.Cookies = r.PostForm.Get("cookies")
+	return req, nil
+}
+
+// checkCookieToken 校验访问口令，支持 Authorization: Bearer 头或请求体中的 token 字段
+func (s *Server) checkCookieToken(r *http.Request, bodyToken string) bool {
+	token := bodyToken
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.cookieToken)) == 1
+}
+
+// clientIP 返回请求来源 IP，不信任可被伪造的 X-Forwarded-For
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// failureLimiter 按 IP 记录口令错误次数，达到上限后锁定一段时间
+type failureLimiter struct {
+	mu          sync.Mutex
+	maxFailures int
+	lockout     time.Duration
+	records     map[string]*failureRecord
+}
+
+// failureRecord 单个 IP 的口令错误记录
+type failureRecord struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// newFailureLimiter 创建口令错误锁定器
+func newFailureLimiter(maxFailures int, lockout time.Duration) *failureLimiter {
+	return &failureLimiter{
+		maxFailures: maxFailures,
+		lockout:     lockout,
+		records:     make(map[string]*failureRecord),
+	}
+}
+
+// lockedUntil 返回 IP 的锁定截止时间，未锁定时返回零值
+func (l *failureLimiter) lockedUntil(ip string, now time.Time) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	record, ok := l.records[ip]
+	if !ok || !now.Before(record.lockedUntil) {
+		return time.Time{}
+	}
+	return record.lockedUntil
+}
+
+// fail 记录一次口令错误，达到上限时锁定并返回 true
+// 超过锁定时长没有再出错的记录会被清理，错误次数重新计算
+func (l *failureLimiter) fail(ip string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, record := range l.records {
+		if now.Sub(record.lastFailure) >= l.lockout && !now.Before(record.lockedUntil) {
+			delete(l.records, key)
+		}
+	}
+
+	record, ok := l.records[ip]
+	if !ok {
+		record = &failureRecord{}
+		l.records[ip] = record
+	}
+	record.failures++
+	record.lastFailure = now
+
+	if record.failures >= l.maxFailures {
+		record.failures = 0
+		record.lockedUntil = now.Add(l.lockout)
+		return true
+	}
+	return false
+}
+
+// reset 口令正确后清除错误记录
+func (l *failureLimiter) reset(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.records, ip)
+}
@@ -0,0 +1,111 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeCookieUpdater 模拟 Cookie 验证，delay 模拟实时请求订单接口的耗时
+type fakeCookieUpdater struct {
+	delay time.Duration
+}
+
+func (f *fakeCookieUpdater) TestAndUpdateCookie(cookies string) (string, error) {
+	time.Sleep(f.delay)
+	return "预计 4-6 周交付", nil
+}
+
+func newCookieTestServer(t *testing.T, delay time.Duration) *Server {
+	t.Helper()
+	s, err := NewServer(nil, "order", 0, "")
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	s.SetCookieUpdater(&fakeCookieUpdater{delay: delay}, "secret")
+	return s
+}
+
+func postCookie(s *Server, remoteAddr, token string) *httptest.ResponseRecorder {
+	body := `{"token":"` + token + `","cookies":"X-LX-Token=abc"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/cookie", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	s.handleCookieUpdate(rec, req)
+	return rec
+}
+
+func TestCookieUpdateLocksOutWrongTokens(t *testing.T) {
+	s := newCookieTestServer(t, 0)
+
+	for i := 0; i < cookieTokenMaxFailures; i++ {
+		if rec := postCookie(s, "192.0.2.1:1234", "wrong"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status = %d, want 401", i+1, rec.Code)
+		}
+	}
+
+	// 锁定期间即使口令正确也被拒绝
+	rec := postCookie(s, "192.0.2.1:5678", "secret")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status after lockout = %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("lockout response has no Retry-After header")
+	}
+
+	// 其他 IP 不受影响
+	if rec := postCookie(s, "192.0.2.2:1234", "secret"); rec.Code != http.StatusOK {
+		t.Errorf("other IP status = %d, want 200", rec.Code)
+	}
+}
+
+func TestFailureLimiterResetAndExpiry(t *testing.T) {
+	now := time.Date(2025, 10, 15, 10, 0, 0, 0, time.UTC)
+	l := newFailureLimiter(3, time.Minute)
+
+	l.fail("ip", now)
+	l.fail("ip", now)
+	l.reset("ip")
+	if l.fail("ip", now) {
+		t.Fatal("locked after a reset and a single failure")
+	}
+
+	l.fail("ip", now)
+	if !l.fail("ip", now) {
+		t.Fatal("not locked after reaching the failure limit")
+	}
+	if l.lockedUntil("ip", now.Add(30*time.Second)).IsZero() {
+		t.Error("lock released before the lockout elapsed")
+	}
+	if !l.lockedUntil("ip", now.Add(time.Minute)).IsZero() {
+		t.Error("lock still active after the lockout elapsed")
+	}
+}
+
+func TestCookieUpdateOutlivesServerWriteTimeout(t *testing.T) {
+	s := newCookieTestServer(t, 300*time.Millisecond)
+
+	// 服务器写超时短于验证耗时，接口需延长本次请求的写超时
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(s.handleCookieUpdate))
+	ts.Config.WriteTimeout = 100 * time.Millisecond
+	ts.Start()
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL, "application/json", strings.NewReader(`{"token":"secret","cookies":"X-LX-Token=abc"}`))
+	if err != nil {
+		t.Fatalf("POST /api/cookie error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result CookieUpdateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if !result.Success {
+		t.Errorf("response = %+v, want success", result)
+	}
+}
@@ -0,0 +1,151 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 实时事件类型
+const (
+	EventCheck        = "check"        // 一次订单检查完成
+	EventEstimate     = "estimate"     // 预计交付时间变化
+	EventNotification = "notification" // 通知发送完成
+	EventCookie       = "cookie"       // Cookie 状态变化
+)
+
+// sseHeartbeatInterval 心跳间隔，防止代理因连接空闲而断开
+const sseHeartbeatInterval = 25 * time.Second
+
+// Event 推送给仪表板的实时事件
+type Event struct {
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+// replayEventTypes 新连接建立时重放的事件类型，让页面立即显示当前状态
+var replayEventTypes = []string{EventCheck, EventCookie}
+
+// eventBroker 管理 SSE 订阅者，将事件广播给所有连接
+type eventBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+	last        map[string]Event // 每种类型最近一次的事件
+	closed      chan struct{}
+	closeOnce   sync.Once
+}
+
+// newEventBroker 创建事件广播器
+func newEventBroker() *eventBroker {
+	return &eventBroker{
+		subscribers: make(map[chan Event]struct{}),
+		last:        make(map[string]Event),
+		closed:      make(chan struct{}),
+	}
+}
+
+// subscribe 添加订阅者，并放入最近的检查和 Cookie 状态事件
+func (b *eventBroker) subscribe() chan Event {
+	ch := make(chan Event, 16)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, eventType := range replayEventTypes {
+		if event, ok := b.last[eventType]; ok {
+			ch <- event
+		}
+	}
+	b.subscribers[ch] = struct{}{}
+	return ch
+}
+
+// unsubscribe 移除订阅者
+func (b *eventBroker) unsubscribe(ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.subscribers, ch)
+}
+
+// publish 广播事件，订阅者缓冲区已满时丢弃该订阅者的这条事件，不阻塞发布方
+func (b *eventBroker) publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.last[event.Type] = event
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// close 通知所有连接退出，用于关闭服务器前结束长连接
+func (b *eventBroker) close() {
+	b.closeOnce.Do(func() { close(b.closed) })
+}
+
+// Publish 向所有打开仪表板的浏览器推送事件
+func (s *Server) Publish(eventType string, data interface{}) {
+	s.events.publish(Event{Type: eventType, Time: time.Now(), Data: data})
+}
+
+// handleEvents 处理 SSE 连接
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.sendJSONError(w, "不支持流式响应", http.StatusInternalServerError)
+		return
+	}
+
+	// 长连接不受服务器 WriteTimeout 限制
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("[Web] 取消 SSE 写超时失败: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // 禁用 Nginx 缓冲
+	w.WriteHeader(http.StatusOK)
+
+	// 建议浏览器断线 5 秒后重连
+	fmt.Fprint(w, "retry: 5000\n\n")
+	flusher.Flush()
+
+	ch := s.events.subscribe()
+	defer s.events.unsubscribe(ch)
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.events.closed:
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event := <-ch:
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.Printf("[Web] 序列化事件失败: %v", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package web
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"lixiang-monitor/db"
+	"lixiang-monitor/export"
+)
+
+// handleExport 导出检查记录为文件下载
+//   - format: json、ndjson、csv 或 xlsx，默认 csv
+//   - tz: 导出时间使用的 IANA 时区，例如 Asia/Shanghai，默认服务器本地时区；
+//     不带时区的 since/until 也按该时区解析
+//   - since/until/time_changed/notification_sent/is_approaching: 与 /api/records 相同
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	formatName := query.Get("format")
+	if formatName == "" {
+		formatName = string(export.FormatCSV)
+	}
+	format, err := export.ParseFormat(formatName)
+	if err != nil {
+		s.sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	loc := time.Local
+	if tz := query.Get("tz"); tz != "" {
+		if loc, err = time.LoadLocation(tz); err != nil {
+			s.sendJSONError(w, fmt.Sprintf("未知时区: %s", tz), http.StatusBadRequest)
+			return
+		}
+	}
+
+	filter := db.RecordFilter{OrderID: s.orderID}
+	if err := parseRecordConditions(query, &filter, loc); err != nil {
+		s.sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	records, err := s.database.FindRecords(filter)
+	if err != nil {
+		log.Printf("[Web] 导出记录失败: %v", err)
+		s.sendJSONError(w, "查询记录失败", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, format.Filename(s.orderID, time.Now().In(loc))))
+	w.Header().Set("Cache-Control", "no-store")
+	if err := export.Write(w, format, records, loc); err != nil {
+		log.Printf("[Web] 写出导出文件失败: %v", err)
+	}
+}
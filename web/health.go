@@ -0,0 +1,89 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// readyTimeout 就绪检查的超时时间
+const readyTimeout = 5 * time.Second
+
+// HealthCheck 单项就绪检查的结果
+type HealthCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// HealthChecker 就绪检查接口
+type HealthChecker interface {
+	// Readiness 执行各项就绪检查
+	Readiness(ctx context.Context) []HealthCheck
+}
+
+// HealthResponse /healthz 和 /readyz 的响应
+type HealthResponse struct {
+	Status    string        `json:"status"`
+	StartedAt time.Time     `json:"started_at"`
+	Uptime    string        `json:"uptime"`
+	Checks    []HealthCheck `json:"checks,omitempty"`
+}
+
+// readyBrief 启用访问认证但请求未认证时 /readyz 的响应，只包含状态和未通过的检查项名称
+type readyBrief struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// SetHealthChecker 设置 /readyz 使用的就绪检查
+func (s *Server) SetHealthChecker(checker HealthChecker) {
+	s.healthChecker = checker
+}
+
+// handleHealthz 存活检查：能响应请求即视为存活
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	s.sendHealth(w, http.StatusOK, "ok", nil)
+}
+
+// handleReadyz 就绪检查：所有检查项通过时返回 200，否则返回 503
+// 启用访问认证时，未认证的请求只能看到状态和未通过的检查项名称，不包含检查详情和运行时间
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	var checks []HealthCheck
+	if s.healthChecker != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+		checks = s.healthChecker.Readiness(ctx)
+	}
+
+	statusCode, status, reason := http.StatusOK, "ready", ""
+	for _, check := range checks {
+		if !check.OK {
+			statusCode, status, reason = http.StatusServiceUnavailable, "not_ready", check.Name
+			break
+		}
+	}
+
+	if auth := s.getAuth(); auth.Enabled() && !s.authenticated(r, auth) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(readyBrief{Status: status, Reason: reason})
+		return
+	}
+	s.sendHealth(w, statusCode, status, checks)
+}
+
+// sendHealth 发送健康检查响应
+func (s *Server) sendHealth(w http.ResponseWriter, statusCode int, status string, checks []HealthCheck) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(HealthResponse{
+		Status:    status,
+		StartedAt: s.startedAt,
+		Uptime:    time.Since(s.startedAt).Round(time.Second).String(),
+		Checks:    checks,
+	})
+}
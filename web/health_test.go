@@ -0,0 +1,54 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// staticChecker 返回固定的就绪检查结果
+type staticChecker []HealthCheck
+
+func (c staticChecker) Readiness(ctx context.Context) []HealthCheck {
+	return c
+}
+
+func TestReadyzHidesDetailsWithoutAuth(t *testing.T) {
+	s, err := NewServer(nil, "order", 0, "")
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	s.SetHealthChecker(staticChecker{
+		{Name: "database", OK: true},
+		{Name: "cookie", OK: false, Detail: "最近 3 次请求认证失败"},
+	})
+	s.SetAuth(AuthConfig{BearerToken: "secret"})
+
+	get := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		s.handleReadyz(rec, req)
+		return rec
+	}
+
+	rec := get("")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("unauthenticated status = %d, want 503", rec.Code)
+	}
+	if body := strings.TrimSpace(rec.Body.String()); body != `{"status":"not_ready","reason":"cookie"}` {
+		t.Errorf("unauthenticated body = %s, want only status and reason", body)
+	}
+
+	rec = get("secret")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("authenticated status = %d, want 503", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "认证失败") || !strings.Contains(body, "started_at") {
+		t.Errorf("authenticated body = %s, want full check details", body)
+	}
+}
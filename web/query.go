@@ -0,0 +1,93 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"lixiang-monitor/db"
+	"lixiang-monitor/utils"
+)
+
+// maxPageSize 单页最多返回的记录数
+const maxPageSize = 500
+
+// parseRecordFilter 解析记录查询参数
+//   - limit: 每页记录数，默认 defaultLimit，最大 maxPageSize
+//   - offset: 偏移量分页
+//   - cursor: 上一页响应头 X-Next-Cursor 的值，游标分页，设置后忽略 offset
+//   - since/until: 检查时间范围 [since, until)，until 只给出日期时包含当天
+//   - time_changed/notification_sent/is_approaching: true 或 false
+func (s *Server) parseRecordFilter(r *http.Request, defaultLimit int) (db.RecordFilter, error) {
+	query := r.URL.Query()
+	filter := db.RecordFilter{OrderID: s.orderID, Limit: defaultLimit}
+
+	var err error
+	if filter.Limit, err = intParam(query.Get("limit"), defaultLimit, "limit"); err != nil {
+		return filter, err
+	}
+	if filter.Limit <= 0 {
+		return filter, fmt.Errorf("limit 必须大于 0")
+	}
+	if filter.Limit > maxPageSize {
+		filter.Limit = maxPageSize
+	}
+	if filter.Offset, err = intParam(query.Get("offset"), 0, "offset"); err != nil {
+		return filter, err
+	}
+	if filter.AfterID, err = intParam(query.Get("cursor"), 0, "cursor"); err != nil {
+		return filter, err
+	}
+
+	err = parseRecordConditions(query, &filter, time.Local)
+	return filter, err
+}
+
+// parseRecordConditions 解析时间范围和标记筛选参数，不带时区的时间按 loc 解析
+func parseRecordConditions(query url.Values, filter *db.RecordFilter, loc *time.Location) error {
+	var err error
+	if filter.Since, err = utils.ParseRangeTime(query.Get("since"), loc, false); err != nil {
+		return fmt.Errorf("since 参数无效: %w", err)
+	}
+	if filter.Until, err = utils.ParseRangeTime(query.Get("until"), loc, true); err != nil {
+		return fmt.Errorf("until 参数无效: %w", err)
+	}
+
+	if filter.TimeChanged, err = boolParam(query.Get("time_changed"), "time_changed"); err != nil {
+		return err
+	}
+	if filter.NotificationSent, err = boolParam(query.Get("notification_sent"), "notification_sent"); err != nil {
+		return err
+	}
+	if filter.IsApproaching, err = boolParam(query.Get("is_approaching"), "is_approaching"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// intParam 解析非负整数参数，为空时返回默认值
+func intParam(value string, defaultValue int, name string) (int, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s 参数必须为非负整数", name)
+	}
+	return n, nil
+}
+
+// boolParam 解析布尔参数，为空时返回 nil 表示不过滤
+func boolParam(value, name string) (*bool, error) {
+	if value == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s 参数必须为 true 或 false", name)
+	}
+	return &b, nil
+}
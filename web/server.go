@@ -1,6 +1,8 @@
 package web
 
 import (
+	"context"
+	"crypto/tls"
 	"embed"
 	"encoding/json"
 	"fmt"
@@ -8,6 +10,7 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"lixiang-monitor/db"
@@ -24,6 +27,47 @@ type Server struct {
 	basePath   string
 	httpServer *http.Server
 	templates  *template.Template
+	routes     []route // 外部注册的路由
+
+	// Cookie 在线更新
+	cookieUpdater  CookieUpdater
+	cookieToken    string
+	cookieFailures *failureLimiter // 按 IP 锁定连续输错口令的请求
+
+	// Prometheus 指标
+	metricsHandler http.Handler
+
+	// 健康检查
+	healthChecker HealthChecker
+	startedAt     time.Time
+
+	// 实时事件
+	events *eventBroker
+
+	// 走势图
+	windowProvider DeliveryWindowProvider
+
+	// 访问认证
+	authMu   sync.RWMutex
+	auth     AuthConfig
+	sessions *sessionStore
+
+	// HTTPS
+	certReloader    *certReloader
+	tlsRedirectPort int
+	redirectServer  *http.Server
+}
+
+// CookieUpdater Cookie 在线更新接口
+type CookieUpdater interface {
+	// TestAndUpdateCookie 使用新 Cookie 实时请求订单数据，成功后保存并返回当前预计交付时间
+	TestAndUpdateCookie(cookies string) (estimateTime string, err error)
+}
+
+// route 外部注册的路由
+type route struct {
+	path    string
+	handler http.Handler
 }
 
 // NewServer 创建 Web 服务器实例
@@ -45,16 +89,35 @@ func NewServer(database *db.Database, orderID string, port int, basePath string)
 	}
 
 	server := &Server{
-		database:  database,
-		orderID:   orderID,
-		port:      port,
-		basePath:  basePath,
-		templates: tmpl,
+		database:       database,
+		orderID:        orderID,
+		port:           port,
+		basePath:       basePath,
+		templates:      tmpl,
+		sessions:       &sessionStore{sessions: make(map[string]time.Time)},
+		events:         newEventBroker(),
+		cookieFailures: newFailureLimiter(cookieTokenMaxFailures, cookieTokenLockout),
 	}
 
 	return server, nil
 }
 
+// Handle 注册额外的路由，需在 Start 之前调用
+func (s *Server) Handle(path string, handler http.Handler) {
+	s.routes = append(s.routes, route{path: path, handler: handler})
+}
+
+// SetCookieUpdater 启用 Cookie 在线更新，token 为访问口令，为空时不启用
+func (s *Server) SetCookieUpdater(updater CookieUpdater, token string) {
+	s.cookieUpdater = updater
+	s.cookieToken = token
+}
+
+// SetMetrics 启用 /metrics 接口，需在 Start 之前调用
+func (s *Server) SetMetrics(handler http.Handler) {
+	s.metricsHandler = handler
+}
+
 // Start 启动 Web 服务器
 func (s *Server) Start() error {
 	mux := http.NewServeMux()
@@ -64,31 +127,82 @@ func (s *Server) Start() error {
 	mux.HandleFunc(s.route("/api/stats"), s.handleStats)
 	mux.HandleFunc(s.route("/api/records"), s.handleRecords)
 	mux.HandleFunc(s.route("/api/time-changes"), s.handleTimeChanges)
+	mux.HandleFunc(s.route("/api/events"), s.handleEvents)
+	mux.HandleFunc(s.route("/api/chart"), s.handleChart)
+	mux.HandleFunc(s.route("/api/export"), s.handleExport)
+	mux.HandleFunc(s.route("/calendar.ics"), s.handleCalendar)
+	if s.cookieUpdater != nil && s.cookieToken != "" {
+		mux.HandleFunc(s.route("/cookie"), s.handleCookiePage)
+		mux.HandleFunc(s.route("/api/cookie"), s.handleCookieUpdate)
+	}
+	if s.metricsHandler != nil {
+		mux.Handle(s.route("/metrics"), s.metricsHandler)
+	}
+	for _, r := range s.routes {
+		mux.Handle(s.route(r.path), r.handler)
+	}
+	mux.HandleFunc(s.route("/healthz"), s.handleHealthz)
+	mux.HandleFunc(s.route("/readyz"), s.handleReadyz)
+	mux.HandleFunc(s.route("/login"), s.handleLogin)
+	mux.HandleFunc(s.route("/logout"), s.handleLogout)
 
+	s.startedAt = time.Now()
 	s.httpServer = &http.Server{
 		Addr:         fmt.Sprintf(":%d", s.port),
-		Handler:      s.logMiddleware(mux),
+		Handler:      s.logMiddleware(s.authMiddleware(mux)),
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
 
-	baseURL := fmt.Sprintf("http://localhost:%d%s", s.port, s.basePath)
+	scheme := "http"
+	if s.tlsEnabled() {
+		scheme = "https"
+		s.httpServer.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: s.certReloader.GetCertificate,
+		}
+	}
+
+	baseURL := fmt.Sprintf("%s://localhost:%d%s", scheme, s.port, s.basePath)
 	log.Printf("[Web] 启动 Web 服务器: %s", baseURL)
 
 	go func() {
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if s.tlsEnabled() {
+			// 证书由 TLSConfig.GetCertificate 提供
+			err = s.httpServer.ListenAndServeTLS("", "")
+		} else {
+			err = s.httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Printf("[Web] 服务器启动失败: %v", err)
 		}
 	}()
 
+	if s.tlsEnabled() && s.tlsRedirectPort > 0 {
+		s.startRedirectServer()
+	}
+
 	return nil
 }
 
 // Stop 停止 Web 服务器
-func (s *Server) Stop() error {
-	if s.httpServer != nil {
-		log.Println("[Web] 正在关闭 Web 服务器...")
-		return s.httpServer.Close()
+// 不再接受新连接，并等待正在处理的请求完成；ctx 到期后强制关闭剩余连接
+func (s *Server) Stop(ctx context.Context) error {
+	if s.httpServer == nil {
+		return nil
+	}
+
+	log.Println("[Web] 正在关闭 Web 服务器...")
+	s.events.close()
+	if s.redirectServer != nil {
+		if err := s.redirectServer.Shutdown(ctx); err != nil {
+			s.redirectServer.Close()
+		}
+	}
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		s.httpServer.Close()
+		return fmt.Errorf("等待请求处理完成超时: %w", err)
 	}
 	return nil
 }
@@ -119,10 +233,14 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	auth := s.getAuth()
 	data := map[string]interface{}{
 		"OrderID":  s.orderID,
 		"Title":    "理想汽车订单监控",
 		"BasePath": s.basePath,
+		"Logout":   auth.passwordEnabled(),
+		// 订阅链接带上只读的日历令牌，首页本身需要认证才能访问
+		"CalendarToken": auth.CalendarToken,
 	}
 
 	if err := s.templates.ExecuteTemplate(w, "index.html", data); err != nil {
@@ -147,10 +265,10 @@ type StatsResponse struct {
 func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// 获取记录总数
-	totalRecords, err := s.database.GetRecordsCount(s.orderID)
+	// 使用 SQL 聚合统计记录数、时间变更和通知次数
+	recordStats, err := s.database.GetRecordStats(s.orderID)
 	if err != nil {
-		s.sendJSONError(w, "查询记录总数失败", http.StatusInternalServerError)
+		s.sendJSONError(w, "统计记录失败", http.StatusInternalServerError)
 		return
 	}
 
@@ -161,29 +279,7 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 获取所有记录用于统计
-	allRecords, err := s.database.GetRecordsByOrderID(s.orderID, totalRecords)
-	if err != nil {
-		s.sendJSONError(w, "查询记录失败", http.StatusInternalServerError)
-		return
-	}
-
-	// 统计时间变更和通知次数
-	timeChangedCount := 0
-	notificationCount := 0
-	var firstCheckTime time.Time
-
-	for i, record := range allRecords {
-		if record.TimeChanged {
-			timeChangedCount++
-		}
-		if record.NotificationSent {
-			notificationCount++
-		}
-		if i == len(allRecords)-1 { // 最后一条是最早的
-			firstCheckTime = record.CheckTime
-		}
-	}
+	firstCheckTime := recordStats.FirstCheckTime
 
 	// 计算监控天数
 	monitoringDays := 0
@@ -192,9 +288,9 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 	}
 
 	stats := StatsResponse{
-		TotalRecords:      totalRecords,
-		TimeChangedCount:  timeChangedCount,
-		NotificationCount: notificationCount,
+		TotalRecords:      recordStats.Total,
+		TimeChangedCount:  recordStats.TimeChanged,
+		NotificationCount: recordStats.NotificationSent,
 		MonitoringDays:    monitoringDays,
 		LatestRecord:      latestRecord,
 	}
@@ -212,44 +308,54 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 
 // handleRecords 处理记录查询
 func (s *Server) handleRecords(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	// 获取分页参数
-	limitStr := r.URL.Query().Get("limit")
-	limit := 20
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
-	}
-
-	records, err := s.database.GetRecordsByOrderID(s.orderID, limit)
-	if err != nil {
-		s.sendJSONError(w, "查询记录失败", http.StatusInternalServerError)
-		return
-	}
-
-	json.NewEncoder(w).Encode(records)
+	s.serveRecords(w, r, 20, nil)
 }
 
 // handleTimeChanges 处理时间变更记录查询
 func (s *Server) handleTimeChanges(w http.ResponseWriter, r *http.Request) {
+	timeChanged := true
+	s.serveRecords(w, r, 10, &timeChanged)
+}
+
+// serveRecords 按查询参数分页返回记录
+// 响应体为记录数组，分页信息通过 X-Total-Count、X-Next-Cursor 和 Link 响应头返回
+func (s *Server) serveRecords(w http.ResponseWriter, r *http.Request, defaultLimit int, timeChanged *bool) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// 获取分页参数
-	limitStr := r.URL.Query().Get("limit")
-	limit := 10
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
+	filter, err := s.parseRecordFilter(r, defaultLimit)
+	if err != nil {
+		s.sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if timeChanged != nil {
+		filter.TimeChanged = timeChanged
+	}
+
+	total, err := s.database.CountRecords(filter)
+	if err != nil {
+		s.sendJSONError(w, "查询记录数失败", http.StatusInternalServerError)
+		return
 	}
 
-	records, err := s.database.GetTimeChangedRecords(s.orderID, limit)
+	records, err := s.database.FindRecords(filter)
 	if err != nil {
-		s.sendJSONError(w, "查询时间变更记录失败", http.StatusInternalServerError)
+		s.sendJSONError(w, "查询记录失败", http.StatusInternalServerError)
 		return
 	}
+	if records == nil {
+		records = []*db.DeliveryRecord{}
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	if len(records) == filter.Limit {
+		cursor := strconv.Itoa(records[len(records)-1].ID)
+		w.Header().Set("X-Next-Cursor", cursor)
+
+		next := r.URL.Query()
+		next.Del("offset")
+		next.Set("cursor", cursor)
+		w.Header().Set("Link", fmt.Sprintf("<%s?%s>; rel=\"next\"", r.URL.Path, next.Encode()))
+	}
 
 	json.NewEncoder(w).Encode(records)
 }
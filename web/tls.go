@@ -0,0 +1,160 @@
+package web
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// certCheckInterval 检查证书文件是否变化的最小间隔
+const certCheckInterval = 10 * time.Second
+
+// TLSConfig HTTPS 配置
+type TLSConfig struct {
+	CertFile     string // 证书文件路径（PEM，可包含中间证书）
+	KeyFile      string // 私钥文件路径（PEM）
+	RedirectPort int    // 大于 0 时在该端口监听 HTTP 并重定向到 HTTPS
+}
+
+// certReloader 证书加载器，证书文件更新后自动重新加载，无需重启服务
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	certMod   time.Time
+	keyMod    time.Time
+	lastCheck time.Time
+}
+
+// newCertReloader 创建证书加载器并立即加载一次证书
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// load 读取证书和私钥
+func (r *certReloader) load() error {
+	certMod, keyMod, err := r.modTimes()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("加载证书失败: %w", err)
+	}
+
+	r.cert = &cert
+	r.certMod = certMod
+	r.keyMod = keyMod
+	r.lastCheck = time.Now()
+	return nil
+}
+
+// modTimes 返回证书和私钥文件的修改时间
+func (r *certReloader) modTimes() (time.Time, time.Time, error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("读取证书文件失败: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("读取私钥文件失败: %w", err)
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
+
+// GetCertificate 供 tls.Config 使用，握手时按需检查证书文件是否更新
+// 重新加载失败时继续使用旧证书（例如证书和私钥只更新了其中一个）
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.lastCheck) < certCheckInterval {
+		return r.cert, nil
+	}
+	r.lastCheck = time.Now()
+
+	certMod, keyMod, err := r.modTimes()
+	if err != nil {
+		log.Printf("[Web] ⚠️  检查证书文件失败，继续使用当前证书: %v", err)
+		return r.cert, nil
+	}
+	if certMod.Equal(r.certMod) && keyMod.Equal(r.keyMod) {
+		return r.cert, nil
+	}
+
+	if err := r.load(); err != nil {
+		log.Printf("[Web] ⚠️  重新加载证书失败，继续使用当前证书: %v", err)
+		return r.cert, nil
+	}
+	log.Println("[Web] ✅ 证书已重新加载")
+	return r.cert, nil
+}
+
+// SetTLS 启用 HTTPS，需在 Start 之前调用
+// 会立即加载一次证书，证书或私钥无效时返回错误
+func (s *Server) SetTLS(config TLSConfig) error {
+	if config.CertFile == "" || config.KeyFile == "" {
+		return fmt.Errorf("证书文件和私钥文件都必须配置")
+	}
+	if config.RedirectPort == s.port {
+		return fmt.Errorf("HTTP 重定向端口不能与 HTTPS 端口相同: %d", s.port)
+	}
+
+	reloader, err := newCertReloader(config.CertFile, config.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	s.certReloader = reloader
+	s.tlsRedirectPort = config.RedirectPort
+	return nil
+}
+
+// tlsEnabled 是否启用 HTTPS
+func (s *Server) tlsEnabled() bool {
+	return s.certReloader != nil
+}
+
+// startRedirectServer 在 tlsRedirectPort 上启动 HTTP 到 HTTPS 的重定向服务
+func (s *Server) startRedirectServer() {
+	s.redirectServer = &http.Server{
+		Addr:         fmt.Sprintf(":%d", s.tlsRedirectPort),
+		Handler:      http.HandlerFunc(s.redirectToHTTPS),
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	log.Printf("[Web] 启动 HTTP 重定向服务: 端口 %d -> %d", s.tlsRedirectPort, s.port)
+
+	go func() {
+		if err := s.redirectServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("[Web] HTTP 重定向服务启动失败: %v", err)
+		}
+	}()
+}
+
+// redirectToHTTPS 将请求重定向到相同路径的 HTTPS 地址
+func (s *Server) redirectToHTTPS(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if s.port != 443 {
+		host = net.JoinHostPort(host, strconv.Itoa(s.port))
+	}
+
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+}